@@ -0,0 +1,243 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//
+// AppInsightsOptions configure an AppInsightsHandler.
+type AppInsightsOptions struct {
+	// ConnectionString of the Application Insights resource,
+	// e.g. InstrumentationKey=...;IngestionEndpoint=https://westeurope-5.in.applicationinsights.azure.com/
+	ConnectionString string
+	// RoleName is the cloud role of the telemetry, e.g. the service name.
+	RoleName string
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Records per request, 100 when 0. Requests also happen every FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	Client        *http.Client
+	// OnError receives send failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+type aiEnvelope struct {
+	Name string            `json:"name"`
+	Time string            `json:"time"`
+	IKey string            `json:"iKey"`
+	Tags map[string]string `json:"tags,omitempty"`
+	Data aiData            `json:"data"`
+}
+
+type aiData struct {
+	BaseType string `json:"baseType"`
+	BaseData any    `json:"baseData"`
+}
+
+type aiMessage struct {
+	Ver           int               `json:"ver"`
+	Message       string            `json:"message"`
+	SeverityLevel int               `json:"severityLevel"`
+	Properties    map[string]string `json:"properties,omitempty"`
+}
+
+type aiException struct {
+	Ver           int               `json:"ver"`
+	Exceptions    []aiExceptionInfo `json:"exceptions"`
+	SeverityLevel int               `json:"severityLevel"`
+	Properties    map[string]string `json:"properties,omitempty"`
+}
+
+type aiExceptionInfo struct {
+	TypeName     string `json:"typeName"`
+	Message      string `json:"message"`
+	HasFullStack bool   `json:"hasFullStack"`
+	Stack        string `json:"stack,omitempty"`
+}
+
+//
+// AppInsightsHandler sends records to Azure Monitor Application Insights, as trace telemetry, or
+// exception telemetry from ERROR on, with the severity mapped from the level. trace_id and span_id
+// become the operation id and parent id, correlating the records with the requests in the portal.
+type AppInsightsHandler struct {
+	opts     *AppInsightsOptions
+	ikey     string
+	endpoint string
+	batch    *batcher[aiEnvelope]
+	// props are the flattened attrs from WithAttrs(), prefix the dotted path of the groups.
+	props  map[string]any
+	prefix string
+}
+
+//
+// NewAppInsightsHandler() starts an AppInsightsHandler. Add it with AddSink() and Close() it on
+// shutdown. It fails for a connection string without an instrumentation key.
+func NewAppInsightsHandler(opts AppInsightsOptions) (*AppInsightsHandler, error) {
+	ikey, endpoint := "", "https://dc.services.visualstudio.com/"
+	for _, part := range strings.Split(opts.ConnectionString, ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(k) {
+		case "instrumentationkey":
+			ikey = v
+		case "ingestionendpoint":
+			endpoint = v
+		}
+	}
+	if ikey == "" {
+		return nil, errors.New("slogf: app insights: connection string lacks InstrumentationKey")
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	h := &AppInsightsHandler{opts: &opts, ikey: ikey, endpoint: strings.TrimSuffix(endpoint, "/") + "/v2/track"}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.send, opts.OnError)
+	return h, nil
+}
+
+func (h *AppInsightsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+func (h *AppInsightsHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(map[string]any, len(h.props)+r.NumAttrs())
+	for k, v := range h.props {
+		fields[k] = v
+	}
+	var err error
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Value.Kind() == slog.KindAny && err == nil {
+			err, _ = a.Value.Any().(error)
+		}
+		flattenAttr(fields, h.prefix, a)
+		return true
+	})
+	tags := map[string]string{}
+	if h.opts.RoleName != "" {
+		tags["ai.cloud.role"] = h.opts.RoleName
+	}
+	if id, ok := fields[TraceIDKey].(string); ok {
+		tags["ai.operation.id"] = id
+	}
+	if id, ok := fields[SpanIDKey].(string); ok {
+		tags["ai.operation.parentId"] = id
+	}
+	if r.PC != 0 {
+		fields["source"] = sourceOf(r.PC)
+	}
+	props := make(map[string]string, len(fields))
+	for k, v := range fields {
+		if s, ok := v.(string); ok {
+			props[k] = s
+			continue
+		}
+		b, _ := json.Marshal(v)
+		props[k] = string(b)
+	}
+
+	e := aiEnvelope{Time: r.Time.UTC().Format(time.RFC3339Nano), IKey: h.ikey, Tags: tags}
+	severity := aiSeverity(r.Level)
+	if r.Level >= slog.LevelError {
+		info := aiExceptionInfo{TypeName: "slogf.Error", Message: r.Message}
+		if err != nil {
+			info.TypeName, info.Message = fmt.Sprintf("%T", err), err.Error()
+			props["message"] = r.Message
+		}
+		if r.PC != 0 {
+			info.Stack = goStack(r.PC)
+		}
+		e.Name = "Microsoft.ApplicationInsights.Exception"
+		e.Data = aiData{BaseType: "ExceptionData", BaseData: aiException{Ver: 2, Exceptions: []aiExceptionInfo{info}, SeverityLevel: severity, Properties: props}}
+	} else {
+		e.Name = "Microsoft.ApplicationInsights.Message"
+		e.Data = aiData{BaseType: "MessageData", BaseData: aiMessage{Ver: 2, Message: r.Message, SeverityLevel: severity, Properties: props}}
+	}
+	h.batch.add(e)
+	return nil
+}
+
+func (h *AppInsightsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	props := make(map[string]any, len(h.props)+len(attrs))
+	for k, v := range h.props {
+		props[k] = v
+	}
+	for _, a := range attrs {
+		flattenAttr(props, h.prefix, a)
+	}
+	return &AppInsightsHandler{opts: h.opts, ikey: h.ikey, endpoint: h.endpoint, batch: h.batch, props: props, prefix: h.prefix}
+}
+
+func (h *AppInsightsHandler) WithGroup(name string) slog.Handler {
+	return &AppInsightsHandler{opts: h.opts, ikey: h.ikey, endpoint: h.endpoint, batch: h.batch, props: h.props, prefix: h.prefix + name + "."}
+}
+
+//
+// Flush() sends the queued records.
+func (h *AppInsightsHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after sending the queued records.
+func (h *AppInsightsHandler) Close() error {
+	return h.batch.Close()
+}
+
+//
+// aiSeverity() maps a level to the severity of Application Insights: Verbose, Information,
+// Warning, Error and Critical for FATAL.
+func aiSeverity(level slog.Level) int {
+	switch {
+	case level >= LevelFatal:
+		return 4
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 2
+	case level >= slog.LevelInfo:
+		return 1
+	}
+	return 0
+}
+
+func (h *AppInsightsHandler) send(envelopes []aiEnvelope) error {
+	stampBatch(envelopes, func(e *aiEnvelope, at time.Time) {
+		switch d := e.Data.BaseData.(type) {
+		case aiMessage:
+			d.Properties["emitted_at"] = fmt.Sprint(emittedValue(at))
+		case aiException:
+			d.Properties["emitted_at"] = fmt.Sprint(emittedValue(at))
+		}
+	})
+	body, err := json.Marshal(envelopes)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("app insights track: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("app insights track: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package slogf
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+//
+// ErrorIf() logs msg at ERROR with the error under "error" when err is not nil, and tells whether
+// it did, replacing the if err != nil { Error(...) } three-liner.
+// E.g. ErrorIf(cache.Refresh(), "Cache refresh failed.", "cache", name)
+func ErrorIf(err error, msg string, args ...any) bool {
+	if err == nil {
+		return false
+	}
+	log(context.Background(), slog.LevelError, msg, append(args[:len(args):len(args)], "error", err.Error()))
+	return true
+}
+
+//
+// WarnIf() logs msg at WARN when cond is true, and returns cond.
+// E.g. WarnIf(len(queue) > 1000, "Queue is backing up.", "len", len(queue))
+func WarnIf(cond bool, msg string, args ...any) bool {
+	if !cond {
+		return false
+	}
+	log(context.Background(), slog.LevelWarn, msg, args)
+	return true
+}
+
+//
+// Must() logs msg at FATAL with the error under "error" and exits when err is not nil,
+// streamlining startup code.
+// E.g. Must(db.Ping(), "Database unreachable.", "dsn", dsn)
+func Must(err error, msg string, args ...any) {
+	if err == nil {
+		return
+	}
+	log(context.Background(), LevelFatal, msg, append(args[:len(args):len(args)], "error", err.Error()))
+	exit(msg)
+}
+
+//
+// MustV() returns v, or logs the error at FATAL and exits when err is not nil.
+// E.g. cfg := MustV(loadConfig(path))
+func MustV[T any](v T, err error) T {
+	if err != nil {
+		log(context.Background(), LevelFatal, err.Error(), nil)
+		exit(err.Error())
+	}
+	return v
+}
+
+//
+// LogClose() closes c and logs msg at WARN with the error under "error" when Close fails,
+// covering the ignored Close error of defer f.Close().
+// E.g. defer LogClose(f, "Closing config file.", "path", path)
+func LogClose(c io.Closer, msg string, args ...any) {
+	if err := c.Close(); err != nil {
+		log(context.Background(), slog.LevelWarn, msg, append(args[:len(args):len(args)], "error", err.Error()))
+	}
+}
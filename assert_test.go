@@ -0,0 +1,70 @@
+package slogf
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// spareArgs returns args with spare capacity, the marker past their length telling whether a
+// function appended to the array of the caller.
+func spareArgs() []any {
+	args := make([]any, 2, 4)
+	args[0], args[1] = "k", "v"
+	return append(args, "marker", "marker")[:2]
+}
+
+func TestErrorIf(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	if err := Reinit(WithOutput(io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+	if ErrorIf(nil, "not logged") {
+		t.Error("ErrorIf(nil) = true")
+	}
+	args := spareArgs()
+	if !ErrorIf(errors.New("boom"), "failed", args...) {
+		t.Error("ErrorIf(err) = false")
+	}
+	if full := args[:4]; full[2] != "marker" || full[3] != "marker" {
+		t.Errorf("ErrorIf() wrote into the args of the caller: %v", full)
+	}
+}
+
+func TestMust(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	if err := Reinit(WithOutput(io.Discard), WithFatalPanic(true)); err != nil {
+		t.Fatal(err)
+	}
+	Must(nil, "not logged")
+	args := spareArgs()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Must(err) didn't panic with WithFatalPanic()")
+			}
+		}()
+		Must(errors.New("boom"), "failed", args...)
+	}()
+	if full := args[:4]; full[2] != "marker" || full[3] != "marker" {
+		t.Errorf("Must() wrote into the args of the caller: %v", full)
+	}
+}
+
+// closer is an io.Closer failing with err.
+type closer struct{ err error }
+
+func (c closer) Close() error { return c.err }
+
+func TestLogClose(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	if err := Reinit(WithOutput(io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+	LogClose(closer{}, "not logged")
+	args := spareArgs()
+	LogClose(closer{errors.New("boom")}, "closing", args...)
+	if full := args[:4]; full[2] != "marker" || full[3] != "marker" {
+		t.Errorf("LogClose() wrote into the args of the caller: %v", full)
+	}
+}
@@ -0,0 +1,86 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+type baggageKey struct{}
+
+type baggageConfig struct {
+	keys    []string
+	extract func(ctx context.Context) map[string]string
+}
+
+var (
+	baggage atomic.Pointer[baggageConfig]
+)
+
+//
+// PropagateBaggage() copies the listed W3C Baggage entries of the context, e.g. tenant or experiment,
+// into every record logged with a context, so cross-service dimensions survive into logs.
+// Entries come from ContextWithBaggage(), or from extract when set, e.g. for the OTel SDK:
+//
+//	func(ctx context.Context) map[string]string {
+//	    m := map[string]string{}
+//	    for _, mb := range baggage.FromContext(ctx).Members() {
+//	        m[mb.Key()] = mb.Value()
+//	    }
+//	    return m
+//	}
+//
+// No keys turns the propagation off.
+func PropagateBaggage(extract func(ctx context.Context) map[string]string, keys ...string) {
+	if len(keys) == 0 {
+		baggage.Store(nil)
+		return
+	}
+	baggage.Store(&baggageConfig{keys: keys, extract: extract})
+}
+
+//
+// ContextWithBaggage() returns a copy of ctx carrying the entries of a W3C baggage header,
+// e.g. "tenant=42,experiment=b;ttl=30".
+func ContextWithBaggage(ctx context.Context, header string) context.Context {
+	entries := map[string]string{}
+	for _, member := range strings.Split(header, ",") {
+		// Properties after ';' are not logged.
+		member, _, _ = strings.Cut(member, ";")
+		k, v, ok := strings.Cut(member, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		if u, err := url.PathUnescape(v); err == nil {
+			v = u
+		}
+		entries[k] = v
+	}
+	return context.WithValue(ctx, baggageKey{}, entries)
+}
+
+//
+// baggageAttrs() returns the propagated baggage entries of ctx.
+func baggageAttrs(ctx context.Context) []slog.Attr {
+	b := baggage.Load()
+	if b == nil || ctx == nil {
+		return nil
+	}
+	var entries map[string]string
+	if b.extract != nil {
+		entries = b.extract(ctx)
+	} else {
+		entries, _ = ctx.Value(baggageKey{}).(map[string]string)
+	}
+	var attrs []slog.Attr
+	for _, k := range b.keys {
+		if v, ok := entries[k]; ok {
+			attrs = append(attrs, slog.String(k, v))
+		}
+	}
+	return attrs
+}
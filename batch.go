@@ -0,0 +1,93 @@
+package slogf
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+//
+// batcher collects the items of a sink and sends them when the batch is full or at every interval.
+// It is shared by the network sinks.
+type batcher[T any] struct {
+	mu      sync.Mutex
+	items   []T
+	max     int
+	send    func(items []T) error
+	onError func(err error)
+
+	sendMu sync.Mutex // keeps the batches in order
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newBatcher[T any](max int, interval time.Duration, send func(items []T) error, onError func(err error)) *batcher[T] {
+	if max <= 0 {
+		max = 100
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if onError == nil {
+		onError = func(err error) { fmt.Fprintln(os.Stderr, "slogf:", err) }
+	}
+	report := func(err error) {
+		sinkErrors.Add(1)
+		onError(err)
+	}
+	b := &batcher[T]{max: max, send: send, onError: report, done: make(chan struct{})}
+	go b.run(interval)
+	return b
+}
+
+func (b *batcher[T]) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := b.Flush(); err != nil {
+				b.onError(err)
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+//
+// add() queues item, sending the batch right away once full.
+func (b *batcher[T]) add(item T) {
+	b.mu.Lock()
+	b.items = append(b.items, item)
+	full := len(b.items) >= b.max
+	b.mu.Unlock()
+	if full {
+		if err := b.Flush(); err != nil {
+			b.onError(err)
+		}
+	}
+}
+
+//
+// Flush() sends the queued items.
+func (b *batcher[T]) Flush() error {
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+	b.mu.Lock()
+	items := b.items
+	b.items = nil
+	b.mu.Unlock()
+	if len(items) == 0 {
+		return nil
+	}
+	return b.send(items)
+}
+
+//
+// Close() stops the interval flushes and sends what is left.
+func (b *batcher[T]) Close() error {
+	b.once.Do(func() { close(b.done) })
+	return b.Flush()
+}
@@ -0,0 +1,100 @@
+//
+// benchmarks compares slogf with zap, zerolog and raw slog on identical workloads and prints a
+// markdown table, so the performance claims of slogf can be reproduced.
+//
+// It lives in its own module to keep the dependencies out of slogf. Way to run:
+//
+//   cd benchmarks && go run .
+//
+// All loggers write JSON with the caller to os.DevNull, so the cost of the write syscall is the same.
+//
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/keithshum/slogf"
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type workload struct {
+	name string
+	run  map[string]func()
+}
+
+func main() {
+	out, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// slogf writes to os.Stdout.
+	stdout := os.Stdout
+	os.Stdout = out
+	slogf.SetCIDetection(false)
+	slogf.InitLogging(false, "json")
+	os.Stdout = stdout
+
+	zl := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(out), zap.InfoLevel), zap.AddCaller())
+	zs := zl.Sugar()
+	zr := zerolog.New(out).Level(zerolog.InfoLevel).With().Timestamp().Caller().Logger()
+	sl := slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{AddSource: true}))
+
+	loggers := []string{"slogf", "slog", "zap", "zerolog"}
+	workloads := []workload{
+		{"message", map[string]func(){
+			"slogf":   func() { slogf.Info("Request served.") },
+			"slog":    func() { sl.Info("Request served.") },
+			"zap":     func() { zl.Info("Request served.") },
+			"zerolog": func() { zr.Info().Msg("Request served.") },
+		}},
+		{"5 fields", map[string]func(){
+			"slogf": func() {
+				slogf.Info("Request served.", "method", "GET", "path", "/healthz", "status", 200, "bytes", 1523, "cached", true)
+			},
+			"slog": func() {
+				sl.Info("Request served.", "method", "GET", "path", "/healthz", "status", 200, "bytes", 1523, "cached", true)
+			},
+			"zap": func() {
+				zl.Info("Request served.", zap.String("method", "GET"), zap.String("path", "/healthz"), zap.Int("status", 200), zap.Int("bytes", 1523), zap.Bool("cached", true))
+			},
+			"zerolog": func() {
+				zr.Info().Str("method", "GET").Str("path", "/healthz").Int("status", 200).Int("bytes", 1523).Bool("cached", true).Msg("Request served.")
+			},
+		}},
+		{"printf", map[string]func(){
+			"slogf":   func() { slogf.Infof("Served %s %s with %d.", "GET", "/healthz", 200) },
+			"slog":    func() { sl.Info(fmt.Sprintf("Served %s %s with %d.", "GET", "/healthz", 200)) },
+			"zap":     func() { zs.Infof("Served %s %s with %d.", "GET", "/healthz", 200) },
+			"zerolog": func() { zr.Info().Msgf("Served %s %s with %d.", "GET", "/healthz", 200) },
+		}},
+		{"disabled debug", map[string]func(){
+			"slogf":   func() { slogf.Debug("Cache entry.", "key", "user:42") },
+			"slog":    func() { sl.Debug("Cache entry.", "key", "user:42") },
+			"zap":     func() { zl.Debug("Cache entry.", zap.String("key", "user:42")) },
+			"zerolog": func() { zr.Debug().Str("key", "user:42").Msg("Cache entry.") },
+		}},
+	}
+
+	fmt.Println("| workload | logger | ns/op | B/op | allocs/op |")
+	fmt.Println("|---|---|---:|---:|---:|")
+	for _, w := range workloads {
+		for _, name := range loggers {
+			fn := w.run[name]
+			r := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					fn()
+				}
+			})
+			fmt.Printf("| %s | %s | %d | %d | %d |\n", w.name, name, r.NsPerOp(), r.AllocedBytesPerOp(), r.AllocsPerOp())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+type budgetKey struct{}
+
+type budget struct {
+	max     int64
+	used    atomic.Int64
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+//
+// WithBudget() returns a copy of ctx that caps the records logged with it, or any context derived
+// from it, to max, so one pathological request logging in a tight loop can't starve the pipeline
+// for everyone else. FATAL records always pass. The returned end function, meant to be deferred by
+// the request, logs a WARN record with the number of records dropped if the budget was exceeded.
+// E.g.
+//
+//	ctx, end := WithBudget(r.Context(), 1000)
+//	defer end()
+func WithBudget(ctx context.Context, max int) (context.Context, func()) {
+	b := &budget{max: int64(max)}
+	end := func() {
+		b.once.Do(func() {
+			if dropped := b.dropped.Load(); dropped > 0 {
+				r := slog.NewRecord(now(), slog.LevelWarn, "slogf: log budget exceeded", 0)
+				r.AddAttrs(slog.Int64("budget", b.max), slog.Int64("dropped", dropped))
+				_ = Logger.Handler().Handle(context.WithValue(ctx, internalKey{}, true), r)
+			}
+		})
+	}
+	return context.WithValue(ctx, budgetKey{}, b), end
+}
+
+//
+// overBudget() tells whether r exceeds the budget of ctx, counting it as dropped if so.
+func overBudget(ctx context.Context, r slog.Record) bool {
+	if ctx == nil || r.Level >= LevelFatal || internal(ctx) {
+		return false
+	}
+	b, _ := ctx.Value(budgetKey{}).(*budget)
+	if b == nil {
+		return false
+	}
+	if b.used.Add(1) <= b.max {
+		return false
+	}
+	b.dropped.Add(1)
+	return true
+}
@@ -0,0 +1,51 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+)
+
+//
+// buildInfo() returns the go_version, vcs.revision and vcs.time of the binary, with vcs.modified
+// when built from a dirty tree. The vcs ones are missing for binaries built without VCS stamping.
+func buildInfo() []any {
+	args := []any{"go_version", runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return args
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time":
+			args = append(args, s.Key, s.Value)
+		case "vcs.modified":
+			if s.Value == "true" {
+				args = append(args, s.Key, true)
+			}
+		}
+	}
+	return args
+}
+
+//
+// WithBuildInfo() adds the go_version, vcs.revision and vcs.time of the binary to every record, to
+// correlate the behavior in the logs with the deployed commit. See LogBuildInfo() for a single
+// record at startup instead.
+func WithBuildInfo() Option {
+	return func(s *settings) {
+		WithStaticFields(buildInfo()...)(s)
+	}
+}
+
+//
+// LogBuildInfo() logs an INFO "build info" record with the go_version, vcs.revision and vcs.time
+// of the binary, with the main module path and version, e.g. once after Init().
+func LogBuildInfo() {
+	args := buildInfo()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		args = append(args, "module", info.Main.Path, "version", info.Main.Version)
+	}
+	log(context.Background(), slog.LevelInfo, "build info", args)
+}
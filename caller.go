@@ -0,0 +1,81 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+)
+
+var (
+	// callerSkip is the number of frames of wrappers skipped by the package functions.
+	callerSkip atomic.Int32
+)
+
+//
+// WithCallerSkip() skips n more frames when the package functions, Info(), Infof() and the
+// others, report the source, so a helper package wrapping slogf reports the call site of its
+// callers rather than its own file and line. 0 by default. See CallerSkip() for a single logger.
+// E.g. WithCallerSkip(1) for helpers calling slogf directly
+func WithCallerSkip(n int) Option {
+	return func(s *settings) {
+		if n < 0 {
+			s.errs = append(s.errs, fmt.Errorf("slogf: caller skip %d is negative", n))
+			return
+		}
+		s.callerSkip = n
+	}
+}
+
+//
+// callerSkipKey is the key of the CallerSkip() attr, which is never written out.
+const callerSkipKey = "!slogf.caller_skip"
+
+//
+// CallerSkip() makes a logger derived with Logger.With() skip n frames above the call site when
+// reporting the source, for the wrappers calling the methods of the logger.
+// E.g.
+//
+//	var dbLog = Logger.With(CallerSkip(1))
+//
+//	func logQuery(q string) { dbLog.Info("Query.", "sql", q) }
+func CallerSkip(n int) slog.Attr {
+	return slog.Int(callerSkipKey, n)
+}
+
+//
+// takeAttrs() returns attrs without the ones of key, passing their values to fn, for the attrs
+// that configure a logger rather than being written out.
+func takeAttrs(attrs []slog.Attr, key string, fn func(v slog.Value)) []slog.Attr {
+	for i, a := range attrs {
+		if a.Key == key {
+			kept := attrs[:i:i]
+			for _, a := range attrs[i:] {
+				if a.Key != key {
+					kept = append(kept, a)
+				} else {
+					fn(a.Value)
+				}
+			}
+			return kept
+		}
+	}
+	return attrs
+}
+
+//
+// skipCallers() returns the pc n frames above pc on the stack of the caller, pc when it isn't on
+// it, e.g. for a record handed over to another goroutine.
+func skipCallers(pc uintptr, n int) uintptr {
+	var pcs [64]uintptr
+	k := runtime.Callers(2, pcs[:]) // skip [Callers, skipCallers]
+	for i := 0; i < k; i++ {
+		if pcs[i] == pc {
+			if i+n < k {
+				return pcs[i+n]
+			}
+			break
+		}
+	}
+	return pc
+}
@@ -0,0 +1,139 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type canonicalKey struct{}
+
+type canonicalLine struct {
+	mu    sync.Mutex
+	keys  []string
+	attrs map[string]slog.Attr
+	sums  map[string]float64
+}
+
+//
+// StartCanonical() returns a copy of ctx accumulating the fields of AddCanonical() and
+// SumCanonical(), from anywhere the context goes, and the end function logging them as one INFO
+// record with msg, the Stripe-style canonical log line. args of end are added last.
+// E.g.
+//
+//	ctx, end := StartCanonical(ctx, "job finished")
+//	defer end()
+func StartCanonical(ctx context.Context, msg string) (context.Context, func(args ...any)) {
+	c := &canonicalLine{attrs: map[string]slog.Attr{}, sums: map[string]float64{}}
+	ctx = context.WithValue(ctx, canonicalKey{}, c)
+	var ended atomic.Bool
+	return ctx, func(args ...any) {
+		if ended.Swap(true) {
+			return
+		}
+		AddCanonical(ctx, args...)
+		LogAttrsDepth(ctx, 1, slog.LevelInfo, msg, c.attrList()...)
+	}
+}
+
+//
+// AddCanonical() sets fields of the canonical log line of ctx, if any. A key set again is replaced.
+// E.g. AddCanonical(ctx, "user_id", user.ID, "plan", user.Plan)
+func AddCanonical(ctx context.Context, args ...any) {
+	c := canonicalFrom(ctx)
+	if c == nil || len(args) == 0 {
+		return
+	}
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.Attrs(func(a slog.Attr) bool {
+		if _, ok := c.attrs[a.Key]; !ok {
+			c.keys = append(c.keys, a.Key)
+		}
+		c.attrs[a.Key] = a
+		return true
+	})
+}
+
+//
+// SumCanonical() adds n to a numeric field of the canonical log line of ctx, if any, e.g. the time
+// spent in the database over all the queries of a request.
+// E.g. SumCanonical(ctx, "db_ms", float64(elapsed)/float64(time.Millisecond))
+func SumCanonical(ctx context.Context, key string, n float64) {
+	c := canonicalFrom(ctx)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.attrs[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+	c.sums[key] += n
+	c.attrs[key] = slog.Float64(key, c.sums[key])
+}
+
+//
+// CanonicalMiddleware() makes every request served by next end with one canonical log line,
+// "canonical_log_line", with the http group of the events package plus the fields added during
+// the request.
+func CanonicalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, end := StartCanonical(r.Context(), "canonical_log_line")
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			end(slog.Group("http",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+				slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)),
+			))
+		}()
+		next.ServeHTTP(sw, r.WithContext(ctx))
+	})
+}
+
+func canonicalFrom(ctx context.Context) *canonicalLine {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(canonicalKey{}).(*canonicalLine)
+	return c
+}
+
+func (c *canonicalLine) attrList() []slog.Attr {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	attrs := make([]slog.Attr, len(c.keys))
+	for i, k := range c.keys {
+		attrs[i] = c.attrs[k]
+	}
+	return attrs
+}
+
+//
+// statusWriter records the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+//
+// Unwrap() lets http.ResponseController reach the Flusher and Hijacker of the original writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,90 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+//
+// Longest line of a captured output, longer ones are split.
+const maxCapturedLine = 64 << 10
+
+//
+// CaptureCmd() turns the stdout and stderr of cmd into one record per line at the level, with the
+// cmd name, pid and stream attrs, so the output of the tools a program shells out to doesn't corrupt
+// its JSON stream. The records have the source of the CaptureCmd() call.
+// Call it before starting cmd and the returned function once cmd is waited for, to log a last line
+// without a newline.
+// E.g.
+//
+//	cmd := exec.Command("pg_dump", "orders")
+//	defer CaptureCmd(cmd, slog.LevelInfo)()
+//	err := cmd.Run()
+func CaptureCmd(cmd *exec.Cmd, level slog.Level) (flush func()) {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip [Callers, CaptureCmd]
+	stdout := &lineWriter{cmd: cmd, level: level, stream: "stdout", pc: pcs[0]}
+	stderr := &lineWriter{cmd: cmd, level: level, stream: "stderr", pc: pcs[0]}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return func() {
+		stdout.flush()
+		stderr.flush()
+	}
+}
+
+//
+// lineWriter logs what is written to it line by line.
+type lineWriter struct {
+	cmd    *exec.Cmd
+	level  slog.Level
+	stream string
+	pc     uintptr
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			if len(w.buf) >= maxCapturedLine {
+				w.log(w.buf[:maxCapturedLine])
+				w.buf = append(w.buf[:0], w.buf[maxCapturedLine:]...)
+				continue
+			}
+			return len(p), nil
+		}
+		w.log(bytes.TrimSuffix(w.buf[:i], []byte("\r")))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) log(line []byte) {
+	if !Logger.Enabled(context.Background(), w.level) {
+		return
+	}
+	r := slog.NewRecord(now(), w.level, string(line), w.pc)
+	r.AddAttrs(slog.String("cmd", filepath.Base(w.cmd.Path)))
+	if w.cmd.Process != nil {
+		r.AddAttrs(slog.Int("pid", w.cmd.Process.Pid))
+	}
+	r.AddAttrs(slog.String("stream", w.stream))
+	_ = Logger.Handler().Handle(context.Background(), r)
+}
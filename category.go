@@ -0,0 +1,135 @@
+package slogf
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+//
+// CategoryKey is the key of the Category() attr.
+const CategoryKey = "category"
+
+var (
+	categoriesMu sync.RWMutex
+	// categories maps every known category to whether it is enabled.
+	categories = map[string]bool{}
+	// disabledCategories keeps the path cheap while every category is enabled.
+	disabledCategories atomic.Int32
+)
+
+//
+// Category() tags a record, or a logger with Logger.With(), with an app-level category, e.g. a
+// product feature, orthogonal to the level. Categories are enabled until turned off with
+// EnableCategory() or the CategoryHandler() endpoint, which drops their records below WARN.
+// E.g.
+//
+//	billing := Logger.With(Category("billing"))
+//	billing.Info("Invoice computed.", "lines", n)
+func Category(name string) slog.Attr {
+	categoriesMu.Lock()
+	if _, ok := categories[name]; !ok {
+		categories[name] = true
+	}
+	categoriesMu.Unlock()
+	return slog.String(CategoryKey, name)
+}
+
+//
+// EnableCategory() turns the records of a category below WARN on or off at runtime.
+func EnableCategory(name string, on bool) {
+	categoriesMu.Lock()
+	defer categoriesMu.Unlock()
+	was, known := categories[name]
+	if !known {
+		was = true
+	}
+	categories[name] = on
+	switch {
+	case was && !on:
+		disabledCategories.Add(1)
+	case !was && on:
+		disabledCategories.Add(-1)
+	}
+}
+
+//
+// Categories() returns the known categories and whether each is enabled.
+func Categories() map[string]bool {
+	categoriesMu.RLock()
+	defer categoriesMu.RUnlock()
+	m := make(map[string]bool, len(categories))
+	for k, v := range categories {
+		m[k] = v
+	}
+	return m
+}
+
+//
+// CategoryHandler() is an admin endpoint for the categories. GET lists them as a JSON object of
+// category to enabled, POST with the name and enabled query parameters toggles one.
+// E.g.
+//
+//	http.Handle("/debug/log/categories", CategoryHandler())
+//	curl -X POST 'localhost:8080/debug/log/categories?name=billing&enabled=false'
+func CategoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			name := r.URL.Query().Get("name")
+			on, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+			if name == "" || err != nil {
+				http.Error(w, "name and enabled=true|false are required", http.StatusBadRequest)
+				return
+			}
+			EnableCategory(name, on)
+			notice(slog.LevelInfo, "slogf: category toggled", slog.String("name", name), slog.Bool("enabled", on))
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Categories())
+	})
+}
+
+//
+// categoryEnabled() tells whether a record of the category at the level passes.
+func categoryEnabled(name string, level slog.Level) bool {
+	if name == "" || level >= slog.LevelWarn || disabledCategories.Load() == 0 {
+		return true
+	}
+	categoriesMu.RLock()
+	on, known := categories[name]
+	categoriesMu.RUnlock()
+	return on || !known
+}
+
+//
+// recordCategory() returns the category of the top-level attrs of r, if any.
+func recordCategory(r slog.Record) (name string) {
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == CategoryKey && a.Value.Kind() == slog.KindString {
+			name = a.Value.String()
+			return false
+		}
+		return true
+	})
+	return name
+}
+
+//
+// attrsCategory() returns the category of attrs, if any.
+func attrsCategory(attrs []slog.Attr) string {
+	for _, a := range attrs {
+		if a.Key == CategoryKey && a.Value.Kind() == slog.KindString {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package slogf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestAppendChecksum(t *testing.T) {
+	tests := []struct{ line, want string }{
+		{`time=x level=INFO msg=hi`, `time=x level=INFO msg=hi crc=`},
+		{`{"msg":"hi"}`, `{"msg":"hi","crc":"`},
+		{`{}`, `{"crc":"`},
+		{``, ` crc=`},
+	}
+	for _, tt := range tests {
+		got := appendChecksum([]byte(tt.line))
+		if !bytes.HasPrefix(got, []byte(tt.want)) {
+			t.Errorf("appendChecksum(%q) = %q, want a prefix %q", tt.line, got, tt.want)
+		}
+		if tt.line != "" && tt.line[0] == '{' && !json.Valid(got) {
+			t.Errorf("appendChecksum(%q) = %q, invalid JSON", tt.line, got)
+		}
+		for _, line := range [][]byte{got, append(got, '\n')} {
+			orig, err := VerifyLine(line)
+			if err != nil || string(orig) != tt.line {
+				t.Errorf("VerifyLine(%q) = %q, %v, want %q", line, orig, err, tt.line)
+			}
+		}
+	}
+
+	// appendChecksum() doesn't write into the array of line.
+	line := make([]byte, 0, 64)
+	line = append(line, `{"a":1}`...)
+	appendChecksum(line[:2])
+	if string(line) != `{"a":1}` {
+		t.Errorf("line changed to %q", line)
+	}
+}
+
+func TestVerifyLine(t *testing.T) {
+	text := string(appendChecksum([]byte("level=INFO msg=paid amount=10")))
+	obj := string(appendChecksum([]byte(`{"level":"INFO","msg":"paid","amount":10}`)))
+	tests := []struct {
+		name, line string
+		want       error
+	}{
+		{"no checksum", "level=INFO msg=paid", ErrNoChecksum},
+		{"json without checksum", `{"level":"INFO"}`, ErrNoChecksum},
+		{"empty", "", ErrNoChecksum},
+		{"truncated text", text[:len(text)-3], ErrNoChecksum},
+		{"truncated json", obj[:len(obj)-1], ErrNoChecksum},
+		{"altered text", "level=INFO msg=paid amount=90" + text[len(text)-13:], ErrBadChecksum},
+		{"altered json", `{"level":"INFO","msg":"paid","amount":90` + obj[len(obj)-19:], ErrBadChecksum},
+		{"altered sum", text[:len(text)-1] + "x", ErrBadChecksum},
+	}
+	for _, tt := range tests {
+		if _, err := VerifyLine([]byte(tt.line)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: VerifyLine(%q) = %v, want %v", tt.name, tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestChecksumOutput(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	for _, format := range []string{"text", "json"} {
+		var b bytes.Buffer
+		if err := Reinit(WithFormat(format), WithOutput(&b), WithChecksum(true)); err != nil {
+			t.Fatal(err)
+		}
+		Logger.Info("first", "k", "a\nb")
+		Logger.Warn("second")
+		lines := bytes.SplitAfter(bytes.TrimSuffix(b.Bytes(), []byte("\n")), []byte("\n"))
+		if len(lines) != 2 {
+			t.Fatalf("%s: got %d lines: %q", format, len(lines), b.Bytes())
+		}
+		for _, line := range lines {
+			orig, err := VerifyLine(line)
+			if err != nil {
+				t.Errorf("%s: VerifyLine(%q): %v", format, line, err)
+			}
+			if format == "json" && (!json.Valid(line) || !json.Valid(orig)) {
+				t.Errorf("%s: invalid JSON %q", format, line)
+			}
+		}
+	}
+}
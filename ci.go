@@ -0,0 +1,55 @@
+package slogf
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	// ciDetection is set before the package variables of defaultSettings() are.
+	ciDetection = newCIDetection()
+
+	// Variables set by the common CI systems.
+	ciVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI", "TRAVIS", "JENKINS_URL", "TEAMCITY_VERSION", "TF_BUILD", "BITBUCKET_BUILD_NUMBER", "CODEBUILD_BUILD_ID"}
+)
+
+func newCIDetection() *atomic.Bool {
+	on := new(atomic.Bool)
+	on.Store(true)
+	if v, err := strconv.ParseBool(os.Getenv("SLOGF_CI")); err == nil {
+		on.Store(v)
+	}
+	return on
+}
+
+//
+// SetCIDetection() turns the CI defaults on or off, they are on unless SLOGF_CI=false.
+// When a CI environment is detected, the defaults of Init() become the text format, DEBUG level and
+// full source paths, so test runs are as readable and detailed as possible. WithLevel(),
+// WithFormat() and WithSourceFormat() still win.
+func SetCIDetection(on bool) {
+	ciDetection.Store(on)
+}
+
+//
+// InCI() tells whether the process runs under a CI system, from the environment variables they set.
+func InCI() bool {
+	for _, k := range ciVars {
+		v := os.Getenv(k)
+		if v == "" {
+			continue
+		}
+		if b, err := strconv.ParseBool(v); err == nil && !b {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+//
+// ciDefaults() tells whether defaultSettings() should be the CI defaults.
+func ciDefaults() bool {
+	return ciDetection.Load() && InCI()
+}
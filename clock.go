@@ -0,0 +1,67 @@
+package slogf
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type coarseClock struct {
+	nanos atomic.Int64
+	done  chan struct{}
+}
+
+var (
+	coarse atomic.Pointer[coarseClock]
+	// clock is the clock of WithClock(), nil for the system one.
+	clock atomic.Pointer[func() time.Time]
+)
+
+//
+// WithClock() takes the time of every record from now, whether it comes from the package
+// functions or from Logger, so tests and replay tools get deterministic timestamps, e.g. for
+// golden files. nil goes back to the system clock.
+// E.g. WithClock(func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) })
+func WithClock(now func() time.Time) Option {
+	return func(s *settings) { s.clock = now }
+}
+
+//
+// SetCoarseClock() makes records take their timestamp from a cached clock updated every resolution,
+// trading precision for less time.Now() overhead in tight logging loops. 0 goes back to time.Now().
+// E.g. SetCoarseClock(time.Millisecond)
+func SetCoarseClock(resolution time.Duration) {
+	var c *coarseClock
+	if resolution > 0 {
+		c = &coarseClock{done: make(chan struct{})}
+		c.nanos.Store(time.Now().UnixNano())
+		go c.run(resolution)
+	}
+	if old := coarse.Swap(c); old != nil {
+		close(old.done)
+	}
+}
+
+func (c *coarseClock) run(resolution time.Duration) {
+	t := time.NewTicker(resolution)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			c.nanos.Store(now.UnixNano())
+		case <-c.done:
+			return
+		}
+	}
+}
+
+//
+// now() is the time of a new record, from the clock of WithClock() when there is one.
+func now() time.Time {
+	if c := clock.Load(); c != nil {
+		return (*c)()
+	}
+	if c := coarse.Load(); c != nil {
+		return time.Unix(0, c.nanos.Load())
+	}
+	return time.Now()
+}
@@ -0,0 +1,238 @@
+//
+// slogf-gen generates strongly typed logging functions from a schema of events.
+//
+// Idea is to give every event of a large codebase one function with fixed keys and types,
+// e.g. log.UserSignedUp(userID, plan) instead of log.Info("user signed up", "user_id", userID, "plan", plan)
+// typed slightly differently at every call site.
+//
+// Usage:
+//
+//   slogf-gen -schema events.json -out events_gen.go
+//
+// or from a go:generate directive:
+//
+//   //go:generate go run github.com/keithshum/slogf/cmd/slogf-gen -schema events.json -out events_gen.go
+//
+// E.g. Schema
+//   {
+//     "package": "events",
+//     "context": false,
+//     "events": [
+//       {
+//         "name": "UserSignedUp",
+//         "level": "info",
+//         "message": "user signed up",
+//         "fields": [
+//           {"name": "userID", "key": "user_id", "type": "string"},
+//           {"name": "plan", "type": "string"}
+//         ]
+//       }
+//     ]
+//   }
+//
+// Level is one of debug, info, warn, error. Key defaults to the field name.
+// A field can't be named context, slog, time or slogf, the packages the generated file imports.
+// Type is one of string, int, int64, uint64, float64, bool, duration, time, error, any.
+// With "context": true every function takes a context.Context as its first parameter.
+//
+// A key used by several events must have the same type everywhere, otherwise generation fails.
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"text/template"
+)
+
+type Schema struct {
+	Package string  `json:"package"`
+	Context bool    `json:"context"`
+	Events  []Event `json:"events"`
+}
+
+type Event struct {
+	Name    string  `json:"name"`
+	Level   string  `json:"level"`
+	Message string  `json:"message"`
+	Fields  []Field `json:"fields"`
+}
+
+type Field struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+// Go type and slog attr constructor of each schema type.
+var types = map[string]struct {
+	GoType string
+	Attr   string
+}{
+	"string":   {"string", "slog.String"},
+	"int":      {"int", "slog.Int"},
+	"int64":    {"int64", "slog.Int64"},
+	"uint64":   {"uint64", "slog.Uint64"},
+	"float64":  {"float64", "slog.Float64"},
+	"bool":     {"bool", "slog.Bool"},
+	"duration": {"time.Duration", "slog.Duration"},
+	"time":     {"time.Time", "slog.Time"},
+	"error":    {"error", "slog.Any"},
+	"any":      {"any", "slog.Any"},
+}
+
+// Package names of the generated file, a parameter can't shadow them.
+var imported = map[string]bool{"context": true, "slog": true, "time": true, "slogf": true}
+
+var levels = map[string]string{
+	"debug": "slog.LevelDebug",
+	"info":  "slog.LevelInfo",
+	"warn":  "slog.LevelWarn",
+	"error": "slog.LevelError",
+}
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the JSON event schema")
+	out := flag.String("out", "", "path of the generated file, stdout if empty")
+	flag.Parse()
+
+	if *schemaPath == "" {
+		fmt.Fprintln(os.Stderr, "slogf-gen: -schema is required")
+		os.Exit(2)
+	}
+	if err := run(*schemaPath, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "slogf-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, out string) error {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("parse %s: %w", schemaPath, err)
+	}
+	if err := validate(&s); err != nil {
+		return err
+	}
+	src, err := generate(&s)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
+
+//
+// validate() fills in defaults and checks names, levels, types and that every key keeps one type.
+func validate(s *Schema) error {
+	if s.Package == "" {
+		return fmt.Errorf("package is required")
+	}
+	keyTypes := map[string]string{}
+	names := map[string]bool{}
+	for i := range s.Events {
+		e := &s.Events[i]
+		if !token.IsIdentifier(e.Name) || !token.IsExported(e.Name) {
+			return fmt.Errorf("event %q: name must be an exported Go identifier", e.Name)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("event %q: defined twice", e.Name)
+		}
+		names[e.Name] = true
+		if e.Level == "" {
+			e.Level = "info"
+		}
+		if _, ok := levels[e.Level]; !ok {
+			return fmt.Errorf("event %q: unknown level %q", e.Name, e.Level)
+		}
+		if e.Message == "" {
+			e.Message = e.Name
+		}
+		params := map[string]bool{"ctx": s.Context}
+		for j := range e.Fields {
+			f := &e.Fields[j]
+			if !token.IsIdentifier(f.Name) || token.IsKeyword(f.Name) || params[f.Name] {
+				return fmt.Errorf("event %q: invalid or duplicate field name %q", e.Name, f.Name)
+			}
+			if imported[f.Name] {
+				return fmt.Errorf("event %q: field name %q is a package of the generated file", e.Name, f.Name)
+			}
+			params[f.Name] = true
+			if f.Key == "" {
+				f.Key = f.Name
+			}
+			if _, ok := types[f.Type]; !ok {
+				return fmt.Errorf("event %q field %q: unknown type %q", e.Name, f.Name, f.Type)
+			}
+			if t, ok := keyTypes[f.Key]; ok && t != f.Type {
+				return fmt.Errorf("event %q field %q: key %q is %s here but %s elsewhere", e.Name, f.Name, f.Key, f.Type, t)
+			}
+			keyTypes[f.Key] = f.Type
+		}
+	}
+	return nil
+}
+
+//
+// UsesTime() tells whether the generated code needs to import time.
+func (s *Schema) UsesTime() bool {
+	for _, e := range s.Events {
+		for _, f := range e.Fields {
+			if f.Type == "duration" || f.Type == "time" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var tmpl = template.Must(template.New("gen").Funcs(template.FuncMap{
+	"gotype": func(t string) string { return types[t].GoType },
+	"attr":   func(t string) string { return types[t].Attr },
+	"level":  func(l string) string { return levels[l] },
+}).Parse(`// Code generated by slogf-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"log/slog"{{if .UsesTime}}
+	"time"{{end}}
+
+	"github.com/keithshum/slogf"
+)
+
+{{$ctx := .Context}}
+{{range .Events}}
+// {{.Name}}() logs the {{printf "%q" .Message}} event at {{.Level}} level.
+func {{.Name}}({{if $ctx}}ctx context.Context, {{end}}{{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.Name}} {{gotype $f.Type}}{{end}}) {
+	slogf.LogAttrsDepth({{if $ctx}}ctx{{else}}context.Background(){{end}}, 1, {{level .Level}}, {{printf "%q" .Message}}{{range .Fields}},
+		{{attr .Type}}({{printf "%q" .Key}}, {{.Name}}){{end}})
+}
+{{end}}
+`))
+
+func generate(s *Schema) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events_gen.go")
+	if err := run("testdata/events.json", out); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := "testdata/events_gen.go.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated code differs from %s, run go test -update\n%s", golden, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema Schema
+		want   string
+	}{
+		{"no package", Schema{}, "package is required"},
+		{"unexported event", Schema{Package: "p", Events: []Event{{Name: "tick"}}}, "exported Go identifier"},
+		{"event twice", Schema{Package: "p", Events: []Event{{Name: "Tick"}, {Name: "Tick"}}}, "defined twice"},
+		{"unknown level", Schema{Package: "p", Events: []Event{{Name: "Tick", Level: "trace"}}}, `unknown level "trace"`},
+		{"keyword field", Schema{Package: "p", Events: []Event{{Name: "Tick", Fields: []Field{{Name: "type", Type: "string"}}}}}, "invalid or duplicate field name"},
+		{"duplicate field", Schema{Package: "p", Events: []Event{{Name: "Tick", Fields: []Field{{Name: "a", Type: "int"}, {Name: "a", Type: "int"}}}}}, "invalid or duplicate field name"},
+		{"ctx field", Schema{Package: "p", Context: true, Events: []Event{{Name: "Tick", Fields: []Field{{Name: "ctx", Type: "any"}}}}}, "invalid or duplicate field name"},
+		{"time field", Schema{Package: "p", Events: []Event{{Name: "Tick", Fields: []Field{{Name: "time", Type: "time"}, {Name: "d", Type: "duration"}}}}}, `field name "time" is a package`},
+		{"slog field", Schema{Package: "p", Events: []Event{{Name: "Tick", Fields: []Field{{Name: "slog", Type: "string"}}}}}, `field name "slog" is a package`},
+		{"unknown type", Schema{Package: "p", Events: []Event{{Name: "Tick", Fields: []Field{{Name: "a", Type: "int32"}}}}}, `unknown type "int32"`},
+		{"key types", Schema{Package: "p", Events: []Event{
+			{Name: "A", Fields: []Field{{Name: "id", Type: "int"}}},
+			{Name: "B", Fields: []Field{{Name: "id", Type: "string"}}},
+		}}, `key "id" is string here but int elsewhere`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(&tt.schema)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package slogf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// Config is the logging section of an application config, for InitFromConfig().
+// Empty fields keep the defaults of Init().
+type Config struct {
+	// Level is debug, info, warn, error or fatal, with an optional offset like slog's within DEBUG to
+	// FATAL, e.g. "info+2".
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Format is text, json or one of RegisterFormat().
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Output is stdout, stderr or the path of a file records are appended to.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	// AddSource reports the source of the records, true when unset.
+	AddSource *bool `json:"add_source,omitempty" yaml:"add_source,omitempty"`
+	// FullSource reports the full path of the source file rather than its base name.
+	FullSource bool `json:"full_source,omitempty" yaml:"full_source,omitempty"`
+	// KeyNames renames the built-in time, level, msg and source keys, e.g. {"msg": "message"}, see
+	// WithKeyNames().
+	KeyNames map[string]string `json:"key_names,omitempty" yaml:"key_names,omitempty"`
+	// LevelFormat is upper, lower or numeric, see WithLevelFormat().
+	LevelFormat string `json:"level_format,omitempty" yaml:"level_format,omitempty"`
+	// LevelLabels are the labels of the levels by name, e.g. {"warn": "WARNING"}, see
+	// WithLevelLabels().
+	LevelLabels map[string]string `json:"level_labels,omitempty" yaml:"level_labels,omitempty"`
+	// SourceFormat is base, relative, full or line, see WithSourceFormat().
+	SourceFormat string `json:"source_format,omitempty" yaml:"source_format,omitempty"`
+	// TimeFormat is rfc3339nano, rfc3339, rfc3339ms, rfc3339us, unix or unixms, see WithTimeFormat().
+	TimeFormat string `json:"time_format,omitempty" yaml:"time_format,omitempty"`
+	// Timezone is UTC, Local or an IANA zone name like Europe/Paris the time is written in.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// Sinks are added next to the output, by the names of RegisterSink(). They replace the sinks of
+	// the previous InitFromConfig(), those added by AddSink() staying.
+	Sinks []SinkConfig `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+	// Categories turns categories on or off, see EnableCategory().
+	Categories map[string]bool `json:"categories,omitempty" yaml:"categories,omitempty"`
+	// Sampling is the fraction of the records kept by level name, e.g. {"debug": 0.01}, see
+	// WithSampling().
+	Sampling map[string]float64 `json:"sampling,omitempty" yaml:"sampling,omitempty"`
+	// Redact are the keys of the attrs rendered as "[REDACTED]", see WithRedactKeys().
+	Redact []string `json:"redact,omitempty" yaml:"redact,omitempty"`
+	// StaticFields are added to every record, e.g. {"service": "payments"}, see WithStaticFields().
+	StaticFields map[string]string `json:"static_fields,omitempty" yaml:"static_fields,omitempty"`
+	// HostInfo adds host and pid attrs to every record, see WithHostInfo().
+	HostInfo bool `json:"host_info,omitempty" yaml:"host_info,omitempty"`
+	// BuildInfo adds the Go version and VCS revision to every record, see WithBuildInfo().
+	BuildInfo bool `json:"build_info,omitempty" yaml:"build_info,omitempty"`
+	// Checksum ends every line with its CRC, see WithChecksum().
+	Checksum bool `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	// MultilineFold is the marker of the continuation lines of the text format, e.g. "  | ", see
+	// WithMultilineFold().
+	MultilineFold string `json:"multiline_fold,omitempty" yaml:"multiline_fold,omitempty"`
+	// EmittedAt adds the write time to the queued records, see WithEmittedAt().
+	EmittedAt bool `json:"emitted_at,omitempty" yaml:"emitted_at,omitempty"`
+}
+
+var (
+	outputMu sync.Mutex
+	// outputFile is the file opened for Config.Output, closed when replaced.
+	outputFile io.Closer
+	// output is the writer of the format handler, set by Init().
+	output io.Writer
+)
+
+//
+// InitFromConfig() sets up the global logger from cfg, e.g. the logging section of the app config
+// as unmarshalled, or returns the errors of Validate() leaving the logger as it was.
+func InitFromConfig(cfg Config) error {
+	return applyConfig(cfg, false)
+}
+
+//
+// ApplyConfig() reconfigures the global logger from cfg at runtime, e.g. from an admin endpoint, as
+// InitFromConfig() does even when SetInitOnce() is on. The pipeline is swapped atomically: records
+// in flight finish in the old one, which is then drained, its buffered records flushed and its
+// sinks closed, while the new records go to the new one, so none is lost.
+func ApplyConfig(cfg Config) error {
+	return applyConfig(cfg, true)
+}
+
+func applyConfig(cfg Config, force bool) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	opts, file, err := cfg.options()
+	if err != nil {
+		return err
+	}
+	s := defaultSettings()
+	for _, opt := range opts {
+		opt(&s)
+	}
+	err = errors.Join(s.errs...)
+	var hs []slog.Handler
+	if err == nil {
+		hs, err = newSinks(cfg.Sinks)
+	}
+	if err == nil {
+		err = setup(s, force, func() { stageConfigSinks(hs) })
+		if err != nil {
+			closeHandlers(hs)
+		}
+	}
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}
+	for name, on := range cfg.Categories {
+		EnableCategory(name, on)
+	}
+	outputMu.Lock()
+	old := outputFile
+	outputFile = file
+	outputMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+//
+// InitFromFile() sets up the global logger from a JSON or YAML config file with the fields of
+// Config, e.g.
+//
+//	level: warn
+//	format: json
+//	output: /var/log/app.log
+//
+// so ops can change the logging without recompiling. Files ending in .yaml or .yml are YAML, the
+// others JSON. Unknown fields are an error, to catch typos.
+func InitFromFile(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	return InitFromConfig(cfg)
+}
+
+//
+// LoadConfig() reads the config file of InitFromFile() without applying it.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("slogf: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		v, err := parseYAML(data)
+		if err != nil {
+			return cfg, fmt.Errorf("slogf: %s: %w", path, err)
+		}
+		if data, err = json.Marshal(yamlStrings(v, reflect.TypeOf(cfg))); err != nil {
+			return cfg, fmt.Errorf("slogf: %s: %w", path, err)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("slogf: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+//
+// InitFromEnv() sets up the global logger from the SLOGF_LEVEL, SLOGF_FORMAT, SLOGF_OUTPUT,
+// SLOGF_SOURCE_FORMAT, SLOGF_LEVEL_FORMAT, SLOGF_TIME_FORMAT, SLOGF_TIMEZONE, SLOGF_ADD_SOURCE,
+// SLOGF_STATIC_FIELDS and SLOGF_SINKS environment variables, with the values of the Config fields,
+// for twelve-factor deployments. Unset variables keep the defaults. SLOGF_STATIC_FIELDS are
+// key=value pairs separated by commas, e.g. "service=payments,env=prod", SLOGF_SINKS are separated
+// by semicolons with their params as a query, e.g. "kafka?topic=logs".
+// E.g. SLOGF_LEVEL=debug SLOGF_FORMAT=text SLOGF_ADD_SOURCE=false ./app
+func InitFromEnv() error {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	return InitFromConfig(cfg)
+}
+
+//
+// ConfigFromEnv() reads the environment variables of InitFromEnv() without applying them.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Level:        os.Getenv("SLOGF_LEVEL"),
+		Format:       os.Getenv("SLOGF_FORMAT"),
+		Output:       os.Getenv("SLOGF_OUTPUT"),
+		TimeFormat:   os.Getenv("SLOGF_TIME_FORMAT"),
+		SourceFormat: os.Getenv("SLOGF_SOURCE_FORMAT"),
+		LevelFormat:  os.Getenv("SLOGF_LEVEL_FORMAT"),
+		Timezone:     os.Getenv("SLOGF_TIMEZONE"),
+	}
+	if v := os.Getenv("SLOGF_STATIC_FIELDS"); v != "" {
+		cfg.StaticFields = map[string]string{}
+		for _, kv := range strings.Split(v, ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if k = strings.TrimSpace(k); !ok || k == "" {
+				return cfg, fmt.Errorf("slogf: SLOGF_STATIC_FIELDS=%q, want key=value pairs separated by commas", os.Getenv("SLOGF_STATIC_FIELDS"))
+			}
+			cfg.StaticFields[k] = strings.TrimSpace(v)
+		}
+	}
+	if v := os.Getenv("SLOGF_SINKS"); v != "" {
+		sinks, err := parseSinks(v)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Sinks = sinks
+	}
+	if v := os.Getenv("SLOGF_ADD_SOURCE"); v != "" {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("slogf: SLOGF_ADD_SOURCE=%q, want true or false", v)
+		}
+		cfg.AddSource = &on
+	}
+	return cfg, nil
+}
+
+//
+// options() returns the options of cfg, with the output file it opened, if any.
+func (cfg Config) options() ([]Option, *LogFile, error) {
+	var opts []Option
+	if cfg.Level != "" {
+		level, err := ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithLevel(level))
+	}
+	if cfg.Format != "" {
+		opts = append(opts, WithFormat(cfg.Format))
+	}
+	if cfg.AddSource != nil {
+		opts = append(opts, WithSource(*cfg.AddSource))
+	}
+	if cfg.FullSource {
+		opts = append(opts, WithFullSource(true))
+	}
+	if cfg.SourceFormat != "" {
+		opts = append(opts, WithSourceFormat(cfg.SourceFormat))
+	}
+	if len(cfg.KeyNames) > 0 {
+		opts = append(opts, WithKeyNames(cfg.keyNames()))
+	}
+	if cfg.LevelFormat != "" {
+		opts = append(opts, WithLevelFormat(cfg.LevelFormat))
+	}
+	if len(cfg.LevelLabels) > 0 {
+		labels := make(map[slog.Level]string, len(cfg.LevelLabels))
+		for name, label := range cfg.LevelLabels {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return nil, nil, err
+			}
+			labels[level] = label
+		}
+		opts = append(opts, WithLevelLabels(labels))
+	}
+	if cfg.TimeFormat != "" {
+		opts = append(opts, WithTimeFormat(cfg.TimeFormat))
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("slogf: timezone: %w", err)
+		}
+		opts = append(opts, WithTimezone(loc))
+	}
+	if len(cfg.Sampling) > 0 {
+		rates := make(map[slog.Level]float64, len(cfg.Sampling))
+		for name, rate := range cfg.Sampling {
+			level, err := ParseLevel(name)
+			if err != nil {
+				return nil, nil, err
+			}
+			rates[level] = rate
+		}
+		opts = append(opts, WithSampling(rates))
+	}
+	if len(cfg.Redact) > 0 {
+		opts = append(opts, WithRedactKeys(cfg.Redact...))
+	}
+	if len(cfg.StaticFields) > 0 {
+		var args []any
+		for _, k := range sortedKeys(cfg.StaticFields) {
+			args = append(args, k, cfg.StaticFields[k])
+		}
+		opts = append(opts, WithStaticFields(args...))
+	}
+	if cfg.HostInfo {
+		opts = append(opts, WithHostInfo())
+	}
+	if cfg.BuildInfo {
+		opts = append(opts, WithBuildInfo())
+	}
+	if cfg.Checksum {
+		opts = append(opts, WithChecksum(true))
+	}
+	if cfg.MultilineFold != "" {
+		opts = append(opts, WithMultilineFold(cfg.MultilineFold))
+	}
+	if cfg.EmittedAt {
+		opts = append(opts, WithEmittedAt(true))
+	}
+	var file *LogFile
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+	case "stderr":
+		opts = append(opts, WithOutput(os.Stderr))
+	default:
+		f, err := OpenLogFile(cfg.Output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("slogf: output: %w", err)
+		}
+		file = f
+		opts = append(opts, WithOutput(f))
+	}
+	return opts, file, nil
+}
+
+//
+// Validate() checks cfg without applying it, e.g. in CI or before WatchConfig() swaps a config,
+// returning every problem with the path of its field, e.g.
+//
+//	slogf: config: sinks[1].type: unknown sink "kafak", registered: kafka, loki
+//	slogf: config: sampling.info: rate 1.5 out of range, want 0 to 1
+func (cfg Config) Validate() error {
+	var errs []error
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("slogf: config: %s: %s", path, fmt.Sprintf(format, args...)))
+	}
+	if cfg.Level != "" {
+		if _, err := ParseLevel(cfg.Level); err != nil {
+			fail("level", "%s", strings.TrimPrefix(err.Error(), "slogf: "))
+		}
+	}
+	if f := strings.ToLower(cfg.Format); f != "" && f != "text" && f != "json" {
+		if _, ok := lookupFormat(f); !ok {
+			fail("format", "unknown format %q, want %s", cfg.Format, strings.Join(append([]string{"text", "json"}, registeredFormats()...), ", "))
+		}
+	}
+	if o := strings.ToLower(cfg.Output); o != "" && o != "stdout" && o != "stderr" {
+		if dir, err := os.Stat(filepath.Dir(cfg.Output)); err != nil || !dir.IsDir() {
+			fail("output", "directory of %q doesn't exist", cfg.Output)
+		}
+	}
+	for _, key := range sortedKeys(cfg.KeyNames) {
+		switch key {
+		case slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey:
+		default:
+			fail("key_names."+key, "unknown key, want time, level, msg or source")
+		}
+	}
+	if len(cfg.KeyNames) > 0 {
+		var s settings
+		WithKeyNames(cfg.keyNames())(&s)
+		for _, err := range s.errs {
+			fail("key_names", "%s", strings.TrimPrefix(err.Error(), "slogf: key names: "))
+		}
+	}
+	if cfg.LevelFormat != "" {
+		var s settings
+		WithLevelFormat(cfg.LevelFormat)(&s)
+		if len(s.errs) > 0 {
+			fail("level_format", "unknown level format %q, want upper, lower or numeric", cfg.LevelFormat)
+		}
+	}
+	for _, name := range sortedKeys(cfg.LevelLabels) {
+		if _, err := ParseLevel(name); err != nil {
+			fail("level_labels."+name, "%s", strings.TrimPrefix(err.Error(), "slogf: "))
+		} else if cfg.LevelLabels[name] == "" {
+			fail("level_labels."+name, "empty label")
+		}
+	}
+	if cfg.SourceFormat != "" {
+		var s settings
+		WithSourceFormat(cfg.SourceFormat)(&s)
+		if len(s.errs) > 0 {
+			fail("source_format", "unknown source format %q, want base, relative, full or line", cfg.SourceFormat)
+		}
+	}
+	if cfg.TimeFormat != "" {
+		var s settings
+		WithTimeFormat(cfg.TimeFormat)(&s)
+		if len(s.errs) > 0 {
+			fail("time_format", "unknown time format %q, want rfc3339nano, rfc3339, rfc3339ms, rfc3339us, unix or unixms", cfg.TimeFormat)
+		}
+	}
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			fail("timezone", "unknown time zone %q, want UTC, Local or an IANA name like Europe/Paris", cfg.Timezone)
+		}
+	}
+	for i, sink := range cfg.Sinks {
+		registryMu.RLock()
+		_, ok := sinkFactories[strings.ToLower(sink.Type)]
+		registryMu.RUnlock()
+		switch {
+		case sink.Type == "":
+			fail(fmt.Sprintf("sinks[%d].type", i), "missing")
+		case !ok && len(registeredSinks()) == 0:
+			fail(fmt.Sprintf("sinks[%d].type", i), "unknown sink %q, none registered with RegisterSink()", sink.Type)
+		case !ok:
+			fail(fmt.Sprintf("sinks[%d].type", i), "unknown sink %q, registered: %s", sink.Type, strings.Join(registeredSinks(), ", "))
+		}
+	}
+	for name := range cfg.Categories {
+		if name == "" {
+			fail("categories", "empty category name")
+		}
+	}
+	for _, name := range sortedKeys(cfg.Sampling) {
+		if _, err := ParseLevel(name); err != nil {
+			fail("sampling."+name, "%s", strings.TrimPrefix(err.Error(), "slogf: "))
+		} else if rate := cfg.Sampling[name]; rate < 0 || rate > 1 {
+			fail("sampling."+name, "rate %v out of range, want 0 to 1", rate)
+		}
+	}
+	for i, key := range cfg.Redact {
+		if key == "" {
+			fail(fmt.Sprintf("redact[%d]", i), "empty key")
+		}
+	}
+	for key := range cfg.StaticFields {
+		if key == "" {
+			fail("static_fields", "empty key")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+//
+// ParseLevel() parses a level name, debug, info, warn, error or fatal in any case, with an optional
+// offset like slog's, e.g. "info+2". The levels out of DEBUG to FATAL are rejected, as WithLevel()
+// does.
+func ParseLevel(s string) (slog.Level, error) {
+	name, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		name, offset = s[:i], s[i:]
+	}
+	var level slog.Level
+	if strings.EqualFold(name, "fatal") {
+		n := 0
+		if offset != "" {
+			var err error
+			if n, err = strconv.Atoi(offset); err != nil {
+				return 0, fmt.Errorf("slogf: unknown level %q, want debug, info, warn, error or fatal", s)
+			}
+		}
+		level = LevelFatal + slog.Level(n)
+	} else if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("slogf: unknown level %q, want debug, info, warn, error or fatal", s)
+	}
+	if level < slog.LevelDebug || level > LevelFatal {
+		return 0, fmt.Errorf("slogf: level %q out of range, want DEBUG (%d) to FATAL (%d)", s, slog.LevelDebug, LevelFatal)
+	}
+	return level, nil
+}
+
+//
+// keyNames() returns the KeyNames of the key_names of cfg.
+func (cfg Config) keyNames() KeyNames {
+	return KeyNames{
+		Time:    cfg.KeyNames[slog.TimeKey],
+		Level:   cfg.KeyNames[slog.LevelKey],
+		Message: cfg.KeyNames[slog.MessageKey],
+		Source:  cfg.KeyNames[slog.SourceKey],
+	}
+}
@@ -0,0 +1,124 @@
+package slogf
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"Warn", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"fatal", LevelFatal},
+		{"FATAL", LevelFatal},
+		{"info+2", slog.LevelInfo + 2},
+		{"debug+1", slog.LevelDebug + 1},
+		{"fatal-2", LevelFatal - 2},
+		{"fatal-16", slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "verbose", "fatal+", "fatal+x", "info+x", "+1"} {
+		if _, err := ParseLevel(in); err == nil || !strings.Contains(err.Error(), "unknown level") {
+			t.Errorf("ParseLevel(%q) = %v, want an unknown level error", in, err)
+		}
+	}
+	for _, in := range []string{"fatal+1", "debug-1", "error+5", "fatal-17"} {
+		if _, err := ParseLevel(in); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("ParseLevel(%q) = %v, want an out of range error", in, err)
+		}
+	}
+}
+
+var registerTestSink sync.Once
+
+func TestValidate(t *testing.T) {
+	registerTestSink.Do(func() {
+		RegisterSink("test", func(params map[string]string) (slog.Handler, error) {
+			return slog.NewTextHandler(io.Discard, nil), nil
+		})
+	})
+	valid := Config{
+		Level:        "info+2",
+		Format:       "JSON",
+		Output:       filepath.Join(t.TempDir(), "app.log"),
+		KeyNames:     map[string]string{"msg": "message"},
+		LevelFormat:  "lower",
+		LevelLabels:  map[string]string{"warn": "WARNING"},
+		SourceFormat: "line",
+		TimeFormat:   "unixms",
+		Timezone:     "UTC",
+		Sinks:        []SinkConfig{{Type: "Test"}},
+		Categories:   map[string]bool{"db": true},
+		Sampling:     map[string]float64{"debug": 0, "info": 1},
+		Redact:       []string{"password"},
+		StaticFields: map[string]string{"service": "payments"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("empty config: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"level", Config{Level: "verbose"}, `level: unknown level "verbose"`},
+		{"level out of range", Config{Level: "fatal+1"}, `level: level "fatal+1" out of range, want DEBUG (-4) to FATAL (12)`},
+		{"sampling level out of range", Config{Sampling: map[string]float64{"debug-1": 0.5}}, `sampling.debug-1: level "debug-1" out of range`},
+		{"format", Config{Format: "yaml"}, `format: unknown format "yaml", want text, json`},
+		{"output", Config{Output: "/does/not/exist/app.log"}, `output: directory of "/does/not/exist/app.log" doesn't exist`},
+		{"key name", Config{KeyNames: map[string]string{"message": "m"}}, "key_names.message: unknown key"},
+		{"duplicate key names", Config{KeyNames: map[string]string{"msg": "time"}}, `key_names: time and msg both named "time"`},
+		{"level format", Config{LevelFormat: "camel"}, `level_format: unknown level format "camel"`},
+		{"level label", Config{LevelLabels: map[string]string{"notice": "N"}}, "level_labels.notice: unknown level"},
+		{"empty level label", Config{LevelLabels: map[string]string{"info": ""}}, "level_labels.info: empty label"},
+		{"source format", Config{SourceFormat: "short"}, `source_format: unknown source format "short"`},
+		{"time format", Config{TimeFormat: "iso"}, `time_format: unknown time format "iso"`},
+		{"timezone", Config{Timezone: "Mars/Olympus"}, `timezone: unknown time zone "Mars/Olympus"`},
+		{"sink type", Config{Sinks: []SinkConfig{{Type: "test"}, {}}}, "sinks[1].type: missing"},
+		{"unknown sink", Config{Sinks: []SinkConfig{{Type: "tset"}}}, `sinks[0].type: unknown sink "tset", registered: `},
+		{"category", Config{Categories: map[string]bool{"": true}}, "categories: empty category name"},
+		{"sampling level", Config{Sampling: map[string]float64{"trace": 0.5}}, "sampling.trace: unknown level"},
+		{"sampling rate", Config{Sampling: map[string]float64{"info": 1.5}}, "sampling.info: rate 1.5 out of range"},
+		{"redact", Config{Redact: []string{"a", ""}}, "redact[1]: empty key"},
+		{"static field", Config{StaticFields: map[string]string{"": "x"}}, "static_fields: empty key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), "slogf: config: "+tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Every problem is returned, in the order of the fields.
+	err := Config{Level: "verbose", TimeFormat: "iso", Redact: []string{""}}.Validate()
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 3 {
+		t.Fatalf("got %v, want 3 errors", err)
+	}
+	for i, path := range []string{"level", "time_format", "redact[0]"} {
+		if got := joined.Unwrap()[i].Error(); !strings.HasPrefix(got, "slogf: config: "+path+": ") {
+			t.Errorf("error %d: got %q, want the path %s", i, got, path)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterFormat("console", newConsoleHandler)
+}
+
+//
+// ANSI colors of the console format.
+const (
+	colorReset = "\x1b[0m"
+	colorDim   = "\x1b[2m"
+	colorKey   = "\x1b[36m"
+)
+
+var levelColors = map[slog.Level]string{
+	slog.LevelDebug: "\x1b[90m",
+	slog.LevelInfo:  "\x1b[32m",
+	slog.LevelWarn:  "\x1b[33m",
+	slog.LevelError: "\x1b[31m",
+	LevelFatal:      "\x1b[35;1m",
+}
+
+//
+// consoleHandler is the console format, for reading the records in a terminal during development:
+// a short time, the level, colored on terminals unless NO_COLOR is set, the message and the attrs,
+// wrapped at the width of the terminal with a hanging indentation. The level is written as in the
+// other formats, see WithLevelFormat(). Each line of a multi-line message starts a new line, values
+// holding newlines, like stack traces, follow on indented lines.
+type consoleHandler struct {
+	opts *slog.HandlerOptions
+	w    io.Writer
+	mu   *sync.Mutex
+	// term is the terminal written to, for its width, nil when the output isn't one.
+	term  *os.File
+	color bool
+	// attrs are the rendered attrs of WithAttrs(), groups the open groups.
+	attrs  []consoleItem
+	groups []string
+}
+
+//
+// consoleItem is a word of the message or a key=value of a record, its visible text and its colored
+// one, block the value when it spans lines.
+type consoleItem struct {
+	text, colored string
+	block         string
+	// newline starts the item on a new line, for the lines of a multi-line message.
+	newline bool
+}
+
+func newConsoleHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	h := &consoleHandler{opts: opts, w: w, mu: &sync.Mutex{}}
+	if f, ok := w.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			h.term = f
+			_, noColor := os.LookupEnv("NO_COLOR")
+			h.color = !noColor
+		}
+	}
+	return h
+}
+
+func (h *consoleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *consoleHandler) Handle(ctx context.Context, r slog.Record) error {
+	var items []consoleItem
+	for i, line := range strings.Split(strings.TrimRight(r.Message, "\n"), "\n") {
+		for j, word := range strings.Split(line, " ") {
+			items = append(items, consoleItem{text: word, colored: word, newline: i > 0 && j == 0})
+		}
+	}
+	items = append(items, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		items = h.appendAttr(items, h.groups, a)
+		return true
+	})
+	if h.opts.AddSource && r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		source := sourceFile(f.File) + ":" + strconv.Itoa(f.Line)
+		items = append(items, consoleItem{text: source, colored: h.paint(colorDim, source)})
+	}
+
+	t := r.Time
+	if loc := timeZone.Load(); loc != nil {
+		t = t.In(loc)
+	}
+	level := levelValue(r.Level).String()
+	head := fmt.Sprintf("%s %-5s ", t.Format("15:04:05.000"), level)
+	var b bytes.Buffer
+	b.WriteString(t.Format("15:04:05.000"))
+	b.WriteByte(' ')
+	b.WriteString(h.paint(levelColor(r.Level), fmt.Sprintf("%-5s", level)))
+	b.WriteByte(' ')
+
+	indent := utf8.RuneCountInString(head)
+	width := 0
+	if h.term != nil {
+		width = terminalWidth(h.term)
+	}
+	col, first := indent, true
+	var blocks []string
+	for _, it := range items {
+		n := utf8.RuneCountInString(it.text)
+		switch {
+		case it.newline || (width > 0 && col > indent && col+1+n > width):
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(" ", indent))
+			col = indent
+		case !first:
+			b.WriteByte(' ')
+			col++
+		}
+		first = false
+		b.WriteString(it.colored)
+		col += n
+		if it.block != "" {
+			blocks = append(blocks, it.block)
+		}
+	}
+	for _, block := range blocks {
+		for _, line := range strings.Split(strings.TrimRight(block, "\n"), "\n") {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat(" ", indent+2))
+			b.WriteString(line)
+		}
+	}
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b.Bytes())
+	return err
+}
+
+//
+// appendAttr() appends the items of a, after the replace functions, flattening the groups.
+func (h *consoleHandler) appendAttr(items []consoleItem, groups []string, a slog.Attr) []consoleItem {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return items
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, g := range a.Value.Group() {
+			items = h.appendAttr(items, groups, g)
+		}
+		return items
+	}
+	key := strings.Join(append(groups[:len(groups):len(groups)], a.Key), ".")
+	value := consoleValue(a.Value)
+	it := consoleItem{}
+	if strings.Contains(value, "\n") {
+		// Written below the record line, the item only names it.
+		it.block = value
+		value = "↓"
+	} else if value == "" || strings.ContainsAny(value, " =\"") {
+		value = strconv.Quote(value)
+	}
+	it.text = key + "=" + value
+	it.colored = h.paint(colorKey, key+"=") + value
+	return append(items, it)
+}
+
+func consoleValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return v.String()
+}
+
+func (h *consoleHandler) paint(color, s string) string {
+	if !h.color || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+//
+// levelColor() returns the color of level, the one of the level below it for the levels between.
+func levelColor(level slog.Level) string {
+	for _, l := range []slog.Level{LevelFatal, slog.LevelError, slog.LevelWarn, slog.LevelInfo} {
+		if level >= l {
+			return levelColors[l]
+		}
+	}
+	return levelColors[slog.LevelDebug]
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = h.attrs[:len(h.attrs):len(h.attrs)]
+	for _, a := range attrs {
+		c.attrs = c.appendAttr(c.attrs, h.groups, a)
+	}
+	return &c
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &c
+}
+
+func columnsEnv() int {
+	n, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return n
+}
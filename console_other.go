@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package slogf
+
+import "os"
+
+//
+// terminalWidth() returns the number of columns of $COLUMNS, 0 when unset.
+func terminalWidth(f *os.File) int {
+	return columnsEnv()
+}
@@ -0,0 +1,59 @@
+package slogf
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	defer Reinit(WithOutput(&bytes.Buffer{}))
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts []Option
+		log  func()
+		want string
+	}{
+		{"message and attrs", nil, func() { Info("Request served.", "path", "/a b", "status", 200) },
+			"12:00:00.000 INFO  Request served. path=\"/a b\" status=200\n"},
+		{"multi-line message", nil, func() { Warn("first line\n  second  line\nthird", "k", "v") },
+			"12:00:00.000 WARN  first line\n                     second  line\n                   third k=v\n"},
+		{"multi-line value", nil, func() { Error("Failed.", "stack", "a()\nb()\n", "err", errors.New("boom")) },
+			"12:00:00.000 ERROR Failed. stack=↓ err=boom\n                     a()\n                     b()\n"},
+		{"level format", []Option{WithLevelFormat(LevelFormatLower)}, func() { Info("m") },
+			"12:00:00.000 info  m\n"},
+		{"level labels", []Option{WithLevelLabels(map[slog.Level]string{slog.LevelWarn: "WARNING"})}, func() { Warn("m") },
+			"12:00:00.000 WARNING m\n"},
+		{"groups", nil, func() { Logger.WithGroup("g").With("a", 1).Info("m", slog.Group("h", "b", 2)) },
+			"12:00:00.000 INFO  m g.a=1 g.h.b=2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			opts := append([]Option{WithFormat("console"), WithOutput(&b), WithSource(false), WithLevel(slog.LevelInfo), WithClock(func() time.Time { return at }), WithTimezone(time.UTC)}, tt.opts...)
+			if err := Reinit(opts...); err != nil {
+				t.Fatal(err)
+			}
+			tt.log()
+			if got := b.String(); got != tt.want {
+				t.Errorf("got\n%q, want\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsoleSource(t *testing.T) {
+	defer Reinit(WithOutput(&bytes.Buffer{}))
+	var b bytes.Buffer
+	if err := Reinit(WithFormat("console"), WithOutput(&b), WithLevel(slog.LevelInfo)); err != nil {
+		t.Fatal(err)
+	}
+	Info("m")
+	if !regexp.MustCompile(`^\d\d:\d\d:\d\d\.\d{3} INFO  m console_test\.go:\d+\n$`).MatchString(b.String()) {
+		t.Errorf("got %q", b.String())
+	}
+}
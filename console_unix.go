@@ -0,0 +1,26 @@
+//go:build linux || darwin
+
+package slogf
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+//
+// terminalWidth() returns the number of columns of the terminal f, from TIOCGWINSZ, or $COLUMNS,
+// 0 when unknown.
+func terminalWidth(f *os.File) int {
+	var ws struct{ row, col, x, y uint16 }
+	if conn, err := f.SyscallConn(); err == nil {
+		var errno syscall.Errno
+		_ = conn.Control(func(fd uintptr) {
+			_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+		})
+		if errno == 0 && ws.col > 0 {
+			return int(ws.col)
+		}
+	}
+	return columnsEnv()
+}
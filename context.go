@@ -0,0 +1,78 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+var (
+	skipCancelled atomic.Bool
+)
+
+//
+// SkipCancelled() drops the records below WARN logged with an already cancelled context, reducing
+// the noise of in-flight work torn down during shutdowns and request cancellations. The dropped
+// records are counted in the pipeline stats.
+func SkipCancelled(on bool) {
+	skipCancelled.Store(on)
+}
+
+//
+// cancelled() tells whether a record at the level is dropped as its context is done.
+func cancelled(ctx context.Context, level slog.Level) bool {
+	return level < slog.LevelWarn && ctx != nil && skipCancelled.Load() && ctx.Err() != nil
+}
+
+//
+// Context variants of the level functions. The context reaches the handlers, so context-aware
+// features (baggage, trace ids) can add to the record. DebugContext() lives in debug.go.
+//
+// InfoContext() is Info() with a context.
+func InfoContext(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelInfo, format, args)
+}
+
+//
+// InfofContext() is Infof() with a context.
+func InfofContext(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelInfo, format, args)
+}
+
+//
+// WarnContext() is Warn() with a context.
+func WarnContext(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelWarn, format, args)
+}
+
+//
+// WarnfContext() is Warnf() with a context.
+func WarnfContext(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelWarn, format, args)
+}
+
+//
+// ErrorContext() is Error() with a context.
+func ErrorContext(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelError, format, args)
+}
+
+//
+// ErrorfContext() is Errorf() with a context.
+func ErrorfContext(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelError, format, args)
+}
+
+//
+// FatalContext() is Fatal() with a context.
+func FatalContext(ctx context.Context, format string, args ...any) {
+	log(ctx, LevelFatal, format, args)
+	exit(format)
+}
+
+//
+// FatalfContext() is Fatalf() with a context.
+func FatalfContext(ctx context.Context, format string, args ...any) {
+	logf(ctx, LevelFatal, format, args)
+	exit(sprintf(format, args))
+}
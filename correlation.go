@@ -0,0 +1,159 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+//
+// RequestIDKey is the key of the request ID of the records, RequestIDHeader the header carrying it
+// between services.
+const (
+	RequestIDKey    = "request_id"
+	RequestIDHeader = "X-Request-ID"
+)
+
+//
+// Longest request ID taken from a caller, longer ones are replaced by a new one.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+//
+// requestID is the request ID of a context, children counting the IDs derived from it.
+type requestID struct {
+	id       string
+	children atomic.Int64
+}
+
+//
+// ContextWithRequestID() returns a copy of ctx with the request ID id added as request_id to every
+// record logged with the context. An empty id gets a new one, and so does one a client could use
+// to flood the logs: longer than 128 characters or with other than printable ASCII characters.
+// E.g. ctx := ContextWithRequestID(r.Context(), r.Header.Get(RequestIDHeader))
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	if !validRequestID(id) {
+		id = newID()
+	}
+	return context.WithValue(ctx, requestIDKey{}, &requestID{id: id})
+}
+
+//
+// validRequestID() tells whether id can be taken as is, 1 to 128 printable ASCII characters
+// without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+//
+// RequestIDFromContext() returns the request ID of ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	rid, ok := ctx.Value(requestIDKey{}).(*requestID)
+	if !ok {
+		return "", false
+	}
+	return rid.id, true
+}
+
+//
+// ChildRequestID() returns a copy of ctx with a child of its request ID, the parent ID followed by
+// a dot and the number of the child, abc.1, abc.2 and so on, for the attempts of a retry and the
+// sub-requests of a fan-out, so a multi-hop failure is found from the prefix. ctx without a
+// request ID gets a new one.
+// E.g.
+//
+//	for _, shard := range shards {
+//		go query(ChildRequestID(ctx), shard)
+//	}
+func ChildRequestID(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent, ok := ctx.Value(requestIDKey{}).(*requestID)
+	if !ok {
+		return ContextWithRequestID(ctx, "")
+	}
+	n := parent.children.Add(1)
+	return context.WithValue(ctx, requestIDKey{}, &requestID{id: parent.id + "." + strconv.FormatInt(n, 10)})
+}
+
+//
+// requestIDAttrs() returns the request_id attr of ctx, if any.
+func requestIDAttrs(ctx context.Context) []slog.Attr {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return []slog.Attr{slog.String(RequestIDKey, id)}
+	}
+	return nil
+}
+
+//
+// RequestIDMiddleware() gives every request served by next the request ID of its X-Request-ID
+// header, or a new one when it's missing or invalid, see ContextWithRequestID(), in its context and
+// in the X-Request-ID header of the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := ContextWithRequestID(r.Context(), r.Header.Get(RequestIDHeader))
+		id, _ := RequestIDFromContext(ctx)
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+//
+// Transport() wraps next, http.DefaultTransport when nil, sending every request with a child of
+// the request ID of its context in the X-Request-ID header, and logging it at DEBUG with the
+// method, host, path, status and duration, or at WARN when it fails.
+// E.g. client := &http.Client{Transport: Transport(nil)}
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if _, ok := RequestIDFromContext(ctx); ok {
+		ctx = ChildRequestID(ctx)
+		id, _ := RequestIDFromContext(ctx)
+		req = req.Clone(ctx)
+		req.Header.Set(RequestIDHeader, id)
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)))
+		LogAttrsDepth(ctx, 1, slog.LevelWarn, "http request failed", slog.Attr{Key: "http", Value: slog.GroupValue(attrs...)}, slog.String("error", err.Error()))
+		return nil, err
+	}
+	attrs = append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)),
+	)
+	LogAttrsDepth(ctx, 1, slog.LevelDebug, "http request", slog.Attr{Key: "http", Value: slog.GroupValue(attrs...)})
+	return resp, nil
+}
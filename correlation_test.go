@@ -0,0 +1,42 @@
+package slogf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name, header string
+		kept         bool
+	}{
+		{"kept", "abc-123_X.y", true},
+		{"longest", strings.Repeat("a", 128), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"space", "a b", false},
+		{"control", "a\x1bb", false},
+		{"newline", "a\nb", false},
+		{"non-ASCII", "é", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = RequestIDFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.header)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if (got == tt.header) != tt.kept || !validRequestID(got) {
+				t.Errorf("got request ID %q for %q", got, tt.header)
+			}
+			if w.Header().Get(RequestIDHeader) != got {
+				t.Errorf("response header %q, context %q", w.Header().Get(RequestIDHeader), got)
+			}
+		})
+	}
+}
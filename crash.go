@@ -0,0 +1,164 @@
+package slogf
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+//
+// CrashOptions configures FlushOnSignal().
+type CrashOptions struct {
+	// Sink receives the recent DEBUG records when the signal arrives, e.g. a file handler.
+	Sink slog.Handler
+	// Debug is the number of recent DEBUG records kept, whether the DEBUG level is on or not.
+	// 0 keeps none. While the level is off, only the ones of the package functions are kept, and
+	// without their source, so the DEBUG calls stay cheap.
+	Debug int
+	// Grace bounds the time spent flushing before the shutdown proceeds. Default 5s.
+	Grace time.Duration
+	// Signals default to SIGTERM and SIGINT.
+	Signals []os.Signal
+	// Notified tells that the program gets the signals with its own signal.Notify() too. It then
+	// handles the shutdown itself and the signal isn't raised again.
+	Notified bool
+}
+
+type recordRing struct {
+	mu      sync.Mutex
+	records []slog.Record
+	next    int
+	full    bool
+}
+
+var (
+	debugRing atomic.Pointer[recordRing]
+)
+
+//
+// FlushOnSignal() flushes the buffering sinks, and writes the recent DEBUG records to the crash sink,
+// when the program receives one of the signals, so the records leading to a shutdown are not lost.
+// Flushing is bounded by the grace period. The signal is then raised again with the default
+// handling restored, so it terminates the program as usual, unless opts.Notified is set.
+// The returned stop function turns it off.
+// E.g. defer FlushOnSignal(CrashOptions{Sink: crashFile, Debug: 1000})()
+func FlushOnSignal(opts CrashOptions) (stop func()) {
+	if opts.Grace <= 0 {
+		opts.Grace = 5 * time.Second
+	}
+	if len(opts.Signals) == 0 {
+		opts.Signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	ring := &recordRing{}
+	if opts.Debug > 0 {
+		ring.records = make([]slog.Record, opts.Debug)
+		debugRing.Store(ring)
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, opts.Signals...)
+	go func() {
+		select {
+		case sig := <-ch:
+			notifyShutdown("signal", "signal", "signal", signalName(sig))
+			flushed := make(chan struct{})
+			go func() {
+				defer close(flushed)
+				crashFlush(ring, opts.Sink)
+			}()
+			select {
+			case <-flushed:
+			case <-time.After(opts.Grace):
+				notice(slog.LevelWarn, "slogf: flush grace period exceeded", slog.Duration("grace", opts.Grace))
+			}
+			signal.Stop(ch)
+			debugRing.CompareAndSwap(ring, nil)
+			if opts.Notified {
+				// The program got the signal already.
+				return
+			}
+			signal.Reset(sig)
+			if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+				os.Exit(1)
+			}
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			debugRing.CompareAndSwap(ring, nil)
+		})
+	}
+}
+
+//
+// crashFlush() writes the ring to the crash sink, then flushes it and every other sink.
+func crashFlush(ring *recordRing, sink slog.Handler) {
+	if sink != nil {
+		ctx := context.Background()
+		for _, r := range ring.snapshot() {
+			_ = sink.Handle(ctx, r)
+		}
+		if f, ok := sink.(Flusher); ok {
+			_ = f.Flush()
+		} else if c, ok := sink.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+	_ = closeSinks(false)
+}
+
+//
+// keep() adds a record of the package functions logged while its level is off, without the source.
+func (rr *recordRing) keep(level slog.Level, msg string, args []any) {
+	r := slog.NewRecord(now(), level, msg, 0)
+	r.Add(args...)
+	rr.add(r)
+}
+
+func (rr *recordRing) add(r slog.Record) {
+	rr.mu.Lock()
+	rr.records[rr.next] = r.Clone()
+	rr.next++
+	if rr.next == len(rr.records) {
+		rr.next, rr.full = 0, true
+	}
+	rr.mu.Unlock()
+}
+
+//
+// snapshot() returns the kept records, oldest first.
+func (rr *recordRing) snapshot() []slog.Record {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if !rr.full {
+		return append([]slog.Record(nil), rr.records[:rr.next]...)
+	}
+	return append(append([]slog.Record(nil), rr.records[rr.next:]...), rr.records[:rr.next]...)
+}
+
+//
+// signalName() returns the conventional name of sig, e.g. SIGTERM rather than "terminated".
+func signalName(sig os.Signal) string {
+	switch sig {
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	case syscall.SIGINT:
+		return "SIGINT"
+	case syscall.SIGHUP:
+		return "SIGHUP"
+	case syscall.SIGQUIT:
+		return "SIGQUIT"
+	}
+	return sig.String()
+}
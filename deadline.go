@@ -0,0 +1,39 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+//
+// WarnOnDeadline() logs a WARN record with msg and the key value pairs if the operation is still
+// running when the deadline of ctx passes, even if the caller swallows ctx.Err(), to find slow paths.
+// The record has the source of the WarnOnDeadline() call and the trace of ctx. Call the returned
+// function when the operation ends. A ctx without a deadline logs nothing.
+// E.g.
+//
+//	defer WarnOnDeadline(ctx, "Slow query.", "query", name)()
+func WarnOnDeadline(ctx context.Context, msg string, args ...any) (done func()) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return func() {}
+	}
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip [Callers, WarnOnDeadline]
+	start := time.Now()
+	t := time.AfterFunc(time.Until(deadline), func() {
+		if !Logger.Enabled(ctx, slog.LevelWarn) {
+			return
+		}
+		r := slog.NewRecord(now(), slog.LevelWarn, msg, pcs[0])
+		r.Add(args...)
+		r.AddAttrs(
+			slog.Time("deadline", deadline),
+			slog.Duration("elapsed", time.Since(start).Round(time.Millisecond)),
+		)
+		_ = Logger.Handler().Handle(ctx, r)
+	})
+	return func() { t.Stop() }
+}
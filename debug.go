@@ -0,0 +1,48 @@
+//go:build !slogf_nodebug
+
+package slogf
+
+import (
+	"context"
+	"log/slog"
+)
+
+//
+// Debug() wraps around slog.Debug()
+func Debug(format string, args ...any) {
+	log(context.Background(), slog.LevelDebug, format, args)
+}
+
+//
+// Debugf() provides flexibility to log with the 'printf' style
+func Debugf(format string, args ...any) {
+	logf(context.Background(), slog.LevelDebug, format, args)
+}
+
+//
+// DebugContext() is Debug() with a context.
+func DebugContext(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelDebug, format, args)
+}
+
+//
+// DebugfContext() is Debugf() with a context.
+func DebugfContext(ctx context.Context, format string, args ...any) {
+	logf(ctx, slog.LevelDebug, format, args)
+}
+
+//
+// IfDebug() runs fn only when DEBUG is enabled, so expensive diagnostics, like iterating maps or
+// pretty-printing state, cost nothing in production without if-guards everywhere.
+// E.g.
+//
+//	IfDebug(func(l *slog.Logger) {
+//	    for k, v := range cache {
+//	        l.Debug("Cache entry.", "key", k, "size", len(v))
+//	    }
+//	})
+func IfDebug(fn func(l *slog.Logger)) {
+	if logDebug.Load() && Logger.Enabled(context.Background(), slog.LevelDebug) {
+		fn(Logger)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build slogf_nodebug
+
+package slogf
+
+import (
+	"context"
+	"log/slog"
+)
+
+//
+// Built with -tags slogf_nodebug, the debug functions are empty and get inlined away, so
+// latency-critical binaries pay nothing for them, not even the Enabled check.
+//
+// Debug() is a no-op with the slogf_nodebug build tag.
+func Debug(format string, args ...any) {}
+
+//
+// Debugf() is a no-op with the slogf_nodebug build tag.
+func Debugf(format string, args ...any) {}
+
+//
+// DebugContext() is a no-op with the slogf_nodebug build tag.
+func DebugContext(ctx context.Context, format string, args ...any) {}
+
+//
+// DebugfContext() is a no-op with the slogf_nodebug build tag.
+func DebugfContext(ctx context.Context, format string, args ...any) {}
+
+//
+// IfDebug() never runs fn with the slogf_nodebug build tag.
+func IfDebug(fn func(l *slog.Logger)) {}
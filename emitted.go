@@ -0,0 +1,84 @@
+package slogf
+
+import (
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+//
+// emittedAt tells the sinks to stamp their batches, see WithEmittedAt().
+var emittedAt atomic.Bool
+
+//
+// WithEmittedAt() adds an emitted_at field to the records that wait in a queue before they are
+// written out, with the time they leave it, after the time of the event, so the delay of records
+// queued during a stall shows and their order can be reconstructed. These are the lines of the
+// sharded output, across its shards, and the batches of the Loki, Kinesis, Pub/Sub, Redis,
+// Honeycomb and Application Insights sinks. It is in the time format of WithTimeFormat(), from the
+// clock of WithClock() if any. The other outputs write a record when it's logged and don't need it.
+// E.g. Init(WithEmittedAt(true)) with SetSharding(runtime.GOMAXPROCS(0))
+func WithEmittedAt(on bool) Option {
+	return func(s *settings) { s.emittedAt = on }
+}
+
+//
+// stampBatch() stamps the items of a sink batch about to be sent when WithEmittedAt() is on.
+func stampBatch[T any](items []T, stamp func(item *T, at time.Time)) {
+	if !emittedAt.Load() {
+		return
+	}
+	at := now()
+	for i := range items {
+		stamp(&items[i], at)
+	}
+}
+
+//
+// stampLine() returns the line of a sink with the emitted_at field of at, keeping its newline.
+func stampLine(line []byte, at time.Time) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		return append(appendEmittedAt(line[:n-1], at), '\n')
+	}
+	return appendEmittedAt(line, at)
+}
+
+//
+// emittedValue() returns the emitted_at value of at for the sinks sending objects.
+func emittedValue(at time.Time) any {
+	v, _ := formatTime(at)
+	if v.Kind() == slog.KindTime {
+		return v.Time().Format(time.RFC3339Nano)
+	}
+	return v.Any()
+}
+
+//
+// appendEmittedAt() returns line with the emitted_at field of at, inside the object of a JSON line.
+func appendEmittedAt(line []byte, at time.Time) []byte {
+	v, _ := formatTime(at)
+	json := isJSONLine(line)
+	var s string
+	switch v.Kind() {
+	case slog.KindInt64:
+		s = strconv.FormatInt(v.Int64(), 10)
+	case slog.KindTime:
+		// As the time of the JSON and text handlers.
+		if json {
+			s = strconv.Quote(v.Time().Format(time.RFC3339Nano))
+		} else {
+			s = v.Time().Format("2006-01-02T15:04:05.000Z07:00")
+		}
+	default:
+		s = v.String()
+		if json {
+			s = strconv.Quote(s)
+		}
+	}
+	if json {
+		out := append(line[:len(line)-1:len(line)-1], `,"emitted_at":`...)
+		return append(out, s+"}"...)
+	}
+	return append(line[:len(line):len(line)], " emitted_at="+s...)
+}
@@ -0,0 +1,98 @@
+package slogf
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStampLine(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct{ line, want string }{
+		{`{"msg":"a"}` + "\n", `{"msg":"a","emitted_at":"2024-05-01T12:00:00Z"}` + "\n"},
+		{`{"msg":"a"}`, `{"msg":"a","emitted_at":"2024-05-01T12:00:00Z"}`},
+		{"msg=a\n", "msg=a emitted_at=2024-05-01T12:00:00.000Z\n"},
+	}
+	for _, tt := range tests {
+		if got := string(stampLine([]byte(tt.line), at)); got != tt.want {
+			t.Errorf("stampLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// emittedServer returns a server decoding the JSON bodies posted to it into the values of got.
+func emittedServer(t *testing.T, got chan<- any) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v any
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Error(err)
+		}
+		got <- v
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmittedAtSinks(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := Reinit(WithOutput(io.Discard), WithEmittedAt(true), WithClock(func() time.Time { return at })); err != nil {
+		t.Fatal(err)
+	}
+	const want = "2024-05-01T12:00:00Z"
+	got := make(chan any, 1)
+	srv := emittedServer(t, got)
+
+	ai, err := NewAppInsightsHandler(AppInsightsOptions{ConnectionString: "InstrumentationKey=k;IngestionEndpoint=" + srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ai.Close()
+	logger := slog.New(ai)
+	logger.Info("message")
+	logger.Error("exception")
+	if err := ai.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	envelopes, _ := (<-got).([]any)
+	if len(envelopes) != 2 {
+		t.Fatalf("got %d envelopes", len(envelopes))
+	}
+	for _, e := range envelopes {
+		props := e.(map[string]any)["data"].(map[string]any)["baseData"].(map[string]any)["properties"].(map[string]any)
+		if props["emitted_at"] != want {
+			t.Errorf("app insights: got emitted_at %v, want %s", props["emitted_at"], want)
+		}
+	}
+
+	hc, err := NewHoneycombHandler(HoneycombOptions{APIKey: "k", Dataset: "d", URL: srv.URL, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hc.Close()
+	slog.New(hc).Info("event")
+	if err := hc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := (<-got).([]any)
+	if len(events) != 1 || events[0].(map[string]any)["data"].(map[string]any)["emitted_at"] != want {
+		t.Errorf("honeycomb: got %v, want emitted_at %s", events, want)
+	}
+
+	lk := NewLokiHandler(LokiOptions{URL: srv.URL, FlushInterval: time.Hour})
+	defer lk.Close()
+	slog.New(lk).Info("line")
+	if err := lk.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	push := (<-got).(map[string]any)
+	line := push["streams"].([]any)[0].(map[string]any)["values"].([]any)[0].([]any)[1].(string)
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil || fields["emitted_at"] != want {
+		t.Errorf("loki: got line %q, want emitted_at %s", line, want)
+	}
+}
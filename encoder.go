@@ -0,0 +1,71 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+//
+// recordEncoder turns records into lines with a slog handler writing to a buffer, for the sinks
+// which send lines somewhere else than an io.Writer.
+type recordEncoder struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+	h   slog.Handler
+}
+
+func newJSONEncoder(opts *slog.HandlerOptions) *recordEncoder {
+	buf := &bytes.Buffer{}
+	return &recordEncoder{mu: &sync.Mutex{}, buf: buf, h: slog.NewJSONHandler(buf, opts)}
+}
+
+//
+// encode() returns the line of r, without the trailing newline.
+func (e *recordEncoder) encode(ctx context.Context, r slog.Record) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf.Reset()
+	if err := e.h.Handle(ctx, r); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))), nil
+}
+
+func (e *recordEncoder) WithAttrs(attrs []slog.Attr) *recordEncoder {
+	return &recordEncoder{mu: e.mu, buf: e.buf, h: e.h.WithAttrs(attrs)}
+}
+
+func (e *recordEncoder) WithGroup(name string) *recordEncoder {
+	return &recordEncoder{mu: e.mu, buf: e.buf, h: e.h.WithGroup(name)}
+}
+
+//
+// flattenAttr() adds a to m with dotted keys, "http.path" for path in group http, for the sinks
+// whose backends take flat fields. Values are made of slices and scalars.
+func flattenAttr(m map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		m[prefix+a.Key] = valueToAny(a.Value)
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, g := range a.Value.Group() {
+		flattenAttr(m, prefix, g)
+	}
+}
+
+//
+// sourceOf() returns the file:line of pc, the file shortened as in the output of Init().
+func sourceOf(pc uintptr) string {
+	f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return sourceFile(f.File) + ":" + strconv.Itoa(f.Line)
+}
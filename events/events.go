@@ -0,0 +1,98 @@
+//
+// events provides constructors for the common events logged by services using slogf.
+//
+// Every constructor returns one group attribute with a fixed set of keys and types, so dashboards
+// and alerts built on one service work on all of them.
+//
+// E.g.
+//   log.Info("Request served.", events.HTTPRequest("GET", "/healthz", 200, elapsed))
+//   =>
+//   time=2023-07-11T17:12:46.649Z level=INFO source=main.go:29 msg="Request served." http.method=GET http.path=/healthz http.status=200 http.duration_ms=1.25
+//
+// Durations are always reported as float milliseconds under duration_ms.
+//
+
+package events
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/keithshum/slogf"
+)
+
+// Group keys of the events.
+const (
+	KeyHTTP  = "http"
+	KeyDB    = "db"
+	KeyCache = "cache"
+	KeyJob   = "job"
+)
+
+func init() {
+	str := map[string]any{"type": "string"}
+	integer := map[string]any{"type": "integer"}
+	ms := map[string]any{"type": "number", "description": "duration in milliseconds"}
+	group := func(props map[string]any) map[string]any {
+		return map[string]any{"type": "object", "properties": props}
+	}
+	slogf.RegisterAttrSchema(KeyHTTP, group(map[string]any{"method": str, "path": str, "status": integer, "duration_ms": ms}))
+	slogf.RegisterAttrSchema(KeyDB, group(map[string]any{"system": str, "operation": str, "rows": integer, "duration_ms": ms}))
+	slogf.RegisterAttrSchema(KeyCache, group(map[string]any{"name": str, "key": str, "hit": map[string]any{"type": "boolean"}}))
+	slogf.RegisterAttrSchema(KeyJob, group(map[string]any{"name": str, "attempt": integer, "status": map[string]any{"enum": []any{"ok", "failed"}}, "duration_ms": ms, "error": str}))
+}
+
+//
+// HTTPRequest() describes a served or sent HTTP request.
+func HTTPRequest(method, path string, status int, duration time.Duration) slog.Attr {
+	return slog.Group(KeyHTTP,
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.Int("status", status),
+		durationMs(duration),
+	)
+}
+
+//
+// DBQuery() describes a database query. system is e.g. "postgresql", operation e.g. "SELECT".
+func DBQuery(system, operation string, rows int64, duration time.Duration) slog.Attr {
+	return slog.Group(KeyDB,
+		slog.String("system", system),
+		slog.String("operation", operation),
+		slog.Int64("rows", rows),
+		durationMs(duration),
+	)
+}
+
+//
+// CacheMiss() describes a lookup that missed the named cache.
+func CacheMiss(cache, key string) slog.Attr {
+	return slog.Group(KeyCache,
+		slog.String("name", cache),
+		slog.String("key", key),
+		slog.Bool("hit", false),
+	)
+}
+
+//
+// JobRun() describes one run of a background job. err is nil for a successful run.
+func JobRun(name string, attempt int, duration time.Duration, err error) slog.Attr {
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	attrs := []any{
+		slog.String("name", name),
+		slog.Int("attempt", attempt),
+		slog.String("status", status),
+		durationMs(duration),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	return slog.Group(KeyJob, attrs...)
+}
+
+func durationMs(d time.Duration) slog.Attr {
+	return slog.Float64("duration_ms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,58 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+//
+// ContextExtractor returns the attrs of the request metadata a framework keeps in ctx, nil when
+// there is none.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+var (
+	// extractors are those of RegisterContextExtractor(), copied on write.
+	extractors atomic.Pointer[[]ContextExtractor]
+)
+
+//
+// RegisterContextExtractor() adds the attrs f pulls out of the context to the records logged with
+// one, by the Context functions or the methods of Logger, so a framework can contribute its
+// request metadata, e.g. the session, locale or tenant, without slogf knowing its types. Extractors
+// run in the order registered, typically from an init(), on every record. It panics if f is nil.
+// E.g.
+//
+//	RegisterContextExtractor(func(ctx context.Context) []slog.Attr {
+//		if t, ok := tenant.FromContext(ctx); ok {
+//			return []slog.Attr{slog.String("tenant", t.ID)}
+//		}
+//		return nil
+//	})
+func RegisterContextExtractor(f ContextExtractor) {
+	if f == nil {
+		panic("slogf: RegisterContextExtractor extractor is nil")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	var fs []ContextExtractor
+	if old := extractors.Load(); old != nil {
+		fs = *old
+	}
+	fs = append(fs[:len(fs):len(fs)], f)
+	extractors.Store(&fs)
+}
+
+//
+// extractedAttrs() returns the attrs of the registered extractors for ctx.
+func extractedAttrs(ctx context.Context) []slog.Attr {
+	fs := extractors.Load()
+	if fs == nil || ctx == nil {
+		return nil
+	}
+	var attrs []slog.Attr
+	for _, f := range *fs {
+		attrs = append(attrs, f(ctx)...)
+	}
+	return attrs
+}
@@ -0,0 +1,93 @@
+package slogf
+
+import (
+	"bytes"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+type fieldFrame struct {
+	attrs []slog.Attr
+}
+
+var (
+	fieldsMu sync.Mutex
+	fields   = map[uint64][]*fieldFrame{}
+	// pushed counts the frames of all goroutines, so records skip the lookup when there are none.
+	pushed atomic.Int64
+)
+
+//
+// PushFields() attaches the key value pairs to every record emitted by the current goroutine until
+// the returned pop function runs, for code paths where threading a context is impractical.
+// E.g.
+//
+//	defer PushFields("job", job.ID)()
+func PushFields(args ...any) (pop func()) {
+	r := slog.Record{}
+	r.Add(args...)
+	frame := &fieldFrame{attrs: make([]slog.Attr, 0, r.NumAttrs())}
+	r.Attrs(func(a slog.Attr) bool {
+		frame.attrs = append(frame.attrs, a)
+		return true
+	})
+
+	id := goroutineID()
+	fieldsMu.Lock()
+	fields[id] = append(fields[id], frame)
+	fieldsMu.Unlock()
+	pushed.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			fieldsMu.Lock()
+			frames := fields[id]
+			for i := len(frames) - 1; i >= 0; i-- {
+				if frames[i] == frame {
+					frames = append(frames[:i:i], frames[i+1:]...)
+					break
+				}
+			}
+			if len(frames) == 0 {
+				delete(fields, id)
+			} else {
+				fields[id] = frames
+			}
+			fieldsMu.Unlock()
+			pushed.Add(-1)
+		})
+	}
+}
+
+//
+// goroutineFields() returns the fields pushed by the current goroutine, outermost first.
+func goroutineFields() []slog.Attr {
+	if pushed.Load() == 0 {
+		return nil
+	}
+	id := goroutineID()
+	fieldsMu.Lock()
+	defer fieldsMu.Unlock()
+	var attrs []slog.Attr
+	for _, f := range fields[id] {
+		attrs = append(attrs, f.attrs...)
+	}
+	return attrs
+}
+
+//
+// goroutineID() parses the id of the current goroutine out of its stack header "goroutine 18 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
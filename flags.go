@@ -0,0 +1,47 @@
+package slogf
+
+import (
+	"flag"
+	"sync"
+)
+
+var (
+	flagsMu  sync.Mutex
+	flagsCfg *Config
+)
+
+//
+// RegisterFlags() registers the -log-level, -log-format and -log-output flags on fs, or on the
+// command line flags when fs is nil, for InitFromFlags() to apply after flag.Parse().
+// Their values are those of the Config fields.
+func RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	cfg := &Config{}
+	fs.StringVar(&cfg.Level, "log-level", "info", "log level: debug, info, warn, error or fatal")
+	fs.StringVar(&cfg.Format, "log-format", "json", "log format: text or json")
+	fs.StringVar(&cfg.Output, "log-output", "stdout", "log output: stdout, stderr or a file path")
+	flagsMu.Lock()
+	flagsCfg = cfg
+	flagsMu.Unlock()
+}
+
+//
+// InitFromFlags() sets up the global logger from the flags of RegisterFlags(), once parsed.
+// E.g.
+//
+//	RegisterFlags(nil)
+//	flag.Parse()
+//	if err := InitFromFlags(); err != nil {
+//	    ...
+//	}
+func InitFromFlags() error {
+	flagsMu.Lock()
+	cfg := flagsCfg
+	flagsMu.Unlock()
+	if cfg == nil {
+		return InitFromConfig(Config{})
+	}
+	return InitFromConfig(*cfg)
+}
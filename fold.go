@@ -0,0 +1,111 @@
+package slogf
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//
+// WithMultilineFold() writes the message and the attrs of the text format holding newlines, like
+// stack traces, on continuation lines starting with marker after the record line, so they read
+// well locally while collectors still tell the records apart by the marker. The record line keeps
+// the first line of the value. An empty marker keeps the newlines escaped, the default single-line
+// guarantee. It is off with WithChecksum() and with WithEmittedAt() on the sharded output, which
+// need records on a single line.
+// E.g. WithMultilineFold("  | ")
+func WithMultilineFold(marker string) Option {
+	return func(s *settings) { s.foldMarker = marker }
+}
+
+//
+// foldWriter folds the quoted values with newlines of the lines of a text handler.
+type foldWriter struct {
+	w      io.Writer
+	marker string
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (f *foldWriter) Write(p []byte) (int, error) {
+	if !bytes.Contains(p, []byte(`\n`)) {
+		return f.w.Write(p)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.buf.Reset()
+	f.buf.Write(foldLine(bytes.TrimSuffix(p, []byte("\n")), f.marker))
+	f.buf.WriteByte('\n')
+	if _, err := f.w.Write(f.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+//
+// foldLine() returns line with the quoted values holding newlines cut to their first line, the
+// other lines following on continuation lines prefixed with marker. The control characters of the
+// continuation lines but tabs stay escaped, a \r would start a line without the marker for the
+// collectors splitting on it.
+func foldLine(line []byte, marker string) []byte {
+	var out, rest []byte
+	for i := 0; i < len(line); i++ {
+		if line[i] != '"' {
+			out = append(out, line[i])
+			continue
+		}
+		end := quotedEnd(line, i)
+		if end < 0 {
+			return line
+		}
+		s, err := strconv.Unquote(string(line[i : end+1]))
+		if err != nil || !strings.Contains(s, "\n") {
+			out = append(out, line[i:end+1]...)
+			i = end
+			continue
+		}
+		first, more, folded := strings.Cut(strings.TrimRight(s, "\n"), "\n")
+		out = strconv.AppendQuote(out, first)
+		for _, l := range strings.Split(more, "\n") {
+			if !folded {
+				break
+			}
+			rest = append(rest, '\n')
+			rest = append(rest, marker...)
+			rest = appendFolded(rest, l)
+		}
+		i = end
+	}
+	return append(out, rest...)
+}
+
+//
+// quotedEnd() returns the index of the quote closing the one at start, -1 when none.
+func quotedEnd(line []byte, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+//
+// appendFolded() appends the continuation line l, escaping its control characters but tabs.
+func appendFolded(b []byte, l string) []byte {
+	for _, r := range l {
+		if (r < ' ' && r != '\t') || r == 0x7f {
+			q := strconv.QuoteRune(r)
+			b = append(b, q[1:len(q)-1]...)
+			continue
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	return b
+}
@@ -0,0 +1,50 @@
+package slogf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct{ name, line, want string }{
+		{"no quotes", `level=INFO msg=hi`, `level=INFO msg=hi`},
+		{"no newline", `msg="a b" k="x\ty"`, `msg="a b" k="x\ty"`},
+		{"escaped quote", `msg="say \"hi\"" k=1`, `msg="say \"hi\"" k=1`},
+		{"escaped backslash", `msg="a\\nb"`, `msg="a\\nb"`},
+		{"message", `level=INFO msg="line 1\nline 2\nline 3" k=1`, "level=INFO msg=\"line 1\" k=1\n| line 2\n| line 3"},
+		{"attrs in order", `msg=m a="1\n2" b="x\ny"`, "msg=m a=\"1\" b=\"x\"\n| 2\n| y"},
+		{"trailing newline", `msg="a\n"`, `msg="a"`},
+		{"only a newline", `msg="\n"`, `msg=""`},
+		{"quotes in the lines", `err="failed\n\"x\" at \\tmp"`, "err=\"failed\"\n| \"x\" at \\tmp"},
+		{"control characters", `err="a\nb\tc\rd\x1b[31m"`, "err=\"a\"\n| b\tc\\rd\\x1b[31m"},
+		{"unicode", `msg="é\n日本"`, "msg=\"é\"\n| 日本"},
+		{"unterminated quote", `msg="a\nb`, `msg="a\nb`},
+		{"invalid escape", `k="\q\n"`, `k="\q\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(foldLine([]byte(tt.line), "| ")); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultilineFold(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	var b bytes.Buffer
+	if err := Reinit(WithFormat("text"), WithOutput(&b), WithSource(false), WithMultilineFold("  | ")); err != nil {
+		t.Fatal(err)
+	}
+	Logger.Error("failed", "stack", "main.go:1\nrun.go:2")
+	Logger.Info("single")
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %q", b.String())
+	}
+	if !strings.HasSuffix(lines[0], `msg=failed stack="main.go:1"`) || lines[1] != "  | run.go:2" || !strings.HasSuffix(lines[2], "msg=single") {
+		t.Errorf("got %q", lines)
+	}
+}
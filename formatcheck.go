@@ -0,0 +1,53 @@
+package slogf
+
+import (
+	"sync/atomic"
+)
+
+var (
+	formatCheck atomic.Bool
+)
+
+//
+// EnableFormatCheck() turns on the format validation mode, meant for tests and development.
+// When on, Debugf(), Infof(), Warnf(), Errorf() and Fatalf() compare the number of verbs in the
+// format against the number of arguments and log a WARN diagnostic at the call site on mismatch,
+// instead of quietly printing "%!v(MISSING)" or "%!(EXTRA ...)".
+func EnableFormatCheck(on bool) {
+	formatCheck.Store(on)
+}
+
+//
+// countVerbs() returns how many arguments the format consumes.
+// ok is false when the format uses explicit argument indexes like %[1]v, in which case
+// the count cannot be compared with the arguments.
+func countVerbs(format string) (n int, ok bool) {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		// Flags, width, precision. A '*' takes one more argument.
+		for ; i < len(format); i++ {
+			c := format[i]
+			if c == '[' {
+				return 0, false
+			}
+			if c == '*' {
+				n++
+				continue
+			}
+			if c == '+' || c == '-' || c == '#' || c == ' ' || c == '0' || c == '.' || (c >= '1' && c <= '9') {
+				continue
+			}
+			break
+		}
+		if i < len(format) {
+			n++
+		}
+	}
+	return n, true
+}
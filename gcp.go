@@ -0,0 +1,92 @@
+package slogf
+
+import (
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+//
+// Type of the records Google Cloud Error Reporting groups.
+const gcpErrorType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+type gcpErrors struct {
+	service string
+	version string
+}
+
+var (
+	gcpErrorReporting atomic.Pointer[gcpErrors]
+)
+
+//
+// SetGCPErrorReporting() adds to the ERROR and FATAL records the @type, stack_trace and
+// serviceContext fields Google Cloud Error Reporting expects in JSON logs, so the errors show up
+// grouped in its console with no extra infrastructure. service and version name the serviceContext,
+// which is left out when service is empty.
+// E.g. SetGCPErrorReporting(true, "payments", version)
+func SetGCPErrorReporting(on bool, service, version string) {
+	if !on {
+		gcpErrorReporting.Store(nil)
+		return
+	}
+	gcpErrorReporting.Store(&gcpErrors{service: service, version: version})
+}
+
+//
+// gcpErrorAttrs() returns the Error Reporting attrs of r, if it is reported.
+func gcpErrorAttrs(r slog.Record) []slog.Attr {
+	g := gcpErrorReporting.Load()
+	if g == nil || r.Level < slog.LevelError || r.PC == 0 {
+		return nil
+	}
+	attrs := []slog.Attr{
+		slog.String("@type", gcpErrorType),
+		slog.String("stack_trace", r.Message+"\n\n"+goStack(r.PC)),
+	}
+	if g.service != "" {
+		ctx := []any{slog.String("service", g.service)}
+		if g.version != "" {
+			ctx = append(ctx, slog.String("version", g.version))
+		}
+		attrs = append(attrs, slog.Group("serviceContext", ctx...))
+	}
+	return attrs
+}
+
+//
+// goStack() formats the stack of the current goroutine from the frame of pc, the call site of a
+// record being handled, like a Go panic so Error Reporting parses it.
+func goStack(pc uintptr) string {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(2, pcs) // skip [Callers, goStack]
+	pcs = pcs[:n]
+	for i, p := range pcs {
+		if p == pc {
+			pcs = pcs[i:]
+			break
+		}
+	}
+	if len(pcs) == 0 || pcs[0] != pc {
+		// Not handled on the goroutine of the call site.
+		pcs = []uintptr{pc}
+	}
+	var b strings.Builder
+	b.WriteString("goroutine 1 [running]:\n")
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		b.WriteString(f.Function)
+		b.WriteString("(...)\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+		b.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
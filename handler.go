@@ -0,0 +1,255 @@
+package slogf
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+)
+
+//
+// handler sits in front of the format handler and applies slogf's own processing to every record,
+// whether it comes from the package functions or from Logger directly.
+// It doesn't bind to the format handler: the WithAttrs() and WithGroup() calls are replayed on the
+// current pipeline, so loggers derived before Init(), e.g. in package vars, get the final one.
+type handler struct {
+	ops []handlerOp
+	// resolved caches the ops replayed on the current pipeline.
+	resolved atomic.Pointer[resolvedHandler]
+	// grouped is set once WithGroup() was called, the record attrs are then no longer top-level.
+	grouped bool
+	// category is set by a Category() attr of WithAttrs().
+	category string
+	// skip is set by a CallerSkip() attr of WithAttrs().
+	skip int
+	// sync is set for the logger of Sync().
+	sync bool
+}
+
+//
+// handlerOp is a WithAttrs() call, or a WithGroup() one when group is set.
+type handlerOp struct {
+	attrs []slog.Attr
+	group string
+}
+
+type resolvedHandler struct {
+	p    *pipeline
+	next slog.Handler
+}
+
+//
+// next() returns the current pipeline with the ops of h applied.
+func (h *handler) next() slog.Handler {
+	return h.on(currentPipeline())
+}
+
+//
+// acquire() is next() counting the record in flight in the pipeline, until the returned one is
+// released, so a pipeline being replaced is drained before its sinks are closed.
+func (h *handler) acquire() (slog.Handler, *pipeline) {
+	for {
+		p := currentPipeline()
+		p.inflight.Add(1)
+		if !p.retired.Load() {
+			return h.on(p), p
+		}
+		p.inflight.Add(-1)
+	}
+}
+
+//
+// on() returns p with the ops of h applied.
+func (h *handler) on(p *pipeline) slog.Handler {
+	if len(h.ops) == 0 {
+		return p.next
+	}
+	if r := h.resolved.Load(); r != nil && r.p == p {
+		return r.next
+	}
+	next := p.next
+	for _, op := range h.ops {
+		if op.group != "" {
+			next = next.WithGroup(op.group)
+		} else {
+			next = next.WithAttrs(op.attrs)
+		}
+	}
+	h.resolved.Store(&resolvedHandler{p: p, next: next})
+	return next
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if underPressure(level) || cancelled(ctx, level) {
+		droppedRecords.Add(1)
+		return false
+	}
+	if !categoryEnabled(h.category, level) {
+		return false
+	}
+	return h.levelEnabled(ctx, level)
+}
+
+//
+// levelEnabled() checks the level against the Target() rules and the LevelResolver, if any, or the
+// format handler.
+func (h *handler) levelEnabled(ctx context.Context, level slog.Level) bool {
+	if min, ok := targetLevel(ctx); ok && level >= min {
+		return true
+	}
+	if min, ok := resolveLevel(ctx); ok {
+		return level >= min
+	}
+	return h.next().Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if h.skip > 0 && r.PC != 0 {
+		r.PC = skipCallers(r.PC, h.skip)
+	}
+	if c := clock.Load(); c != nil && !r.Time.IsZero() {
+		r.Time = (*c)()
+	}
+	next := h.next()
+	recordError(ctx, r)
+	if suppressed(r) {
+		droppedRecords.Add(1)
+		return nil
+	}
+	if disabledCategories.Load() > 0 && !h.grouped && !categoryEnabled(recordCategory(r), r.Level) {
+		return nil
+	}
+	if !internal(ctx) && sampledOut(r) {
+		droppedRecords.Add(1)
+		return nil
+	}
+	if overBudget(ctx, r) {
+		droppedRecords.Add(1)
+		return nil
+	}
+	if r.Level >= slog.LevelError && r.Level < LevelFatal && !internal(ctx) {
+		if s := summarizer.Load(); s != nil && !s.summarize(r) {
+			droppedRecords.Add(1)
+			return nil
+		}
+	}
+	r = limitRecord(r)
+	r = sortRecordMaps(r)
+	if !h.grouped {
+		r = h.guardReservedKeys(ctx, r)
+	}
+	if attrs := append(contextAttrs(ctx), gcpErrorAttrs(r)...); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	if r.Level < slog.LevelInfo {
+		if ring := debugRing.Load(); ring != nil {
+			ring.add(r)
+			if !h.levelEnabled(ctx, r.Level) {
+				return nil
+			}
+		}
+	}
+	if validation.Load() && !h.grouped {
+		h.validate(ctx, r)
+	}
+	if (levelResolver.Load() != nil || targeting.Load() > 0) && !next.Enabled(ctx, r.Level) {
+		// Let the sinks below the resolved level take the record too.
+		ctx = context.WithValue(ctx, forcedLevelKey{}, true)
+	}
+	count(r.Level)
+	next, p := h.acquire()
+	err := next.Handle(ctx, r)
+	p.inflight.Add(-1)
+	if err != nil {
+		sinkErrors.Add(1)
+	}
+	if h.sync {
+		err = errors.Join(err, flushNow())
+	}
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	category := h.category
+	if !h.grouped {
+		if c := attrsCategory(attrs); c != "" {
+			category = c
+		}
+	}
+	skip, sync := h.skip, h.sync
+	attrs = takeAttrs(attrs, callerSkipKey, func(v slog.Value) {
+		if v.Kind() == slog.KindInt64 {
+			skip = max(int(v.Int64()), 0)
+		}
+	})
+	attrs = takeAttrs(attrs, syncKey, func(slog.Value) { sync = true })
+	if len(attrs) == 0 {
+		return &handler{ops: h.ops, grouped: h.grouped, category: category, skip: skip, sync: sync}
+	}
+	attrs = limitAttrs(attrs)
+	if !h.grouped {
+		attrs = renameReservedAttrs(attrs)
+	}
+	ops := append(h.ops[:len(h.ops):len(h.ops)], handlerOp{attrs: attrs})
+	return &handler{ops: ops, grouped: h.grouped, category: category, skip: skip, sync: sync}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	ops := append(h.ops[:len(h.ops):len(h.ops)], handlerOp{group: name})
+	return &handler{ops: ops, grouped: true, category: h.category, skip: h.skip, sync: h.sync}
+}
+
+//
+// diagnose() writes a slogf diagnostic about r, with the same source, straight to the format handler.
+func (h *handler) diagnose(ctx context.Context, r slog.Record, level slog.Level, msg string, args ...any) {
+	d := slog.NewRecord(r.Time, level, msg, r.PC)
+	d.Add(args...)
+	_ = h.next().Handle(ctx, d)
+}
+
+//
+// notice() logs a record of slogf itself, with no call site, through the global logger.
+func notice(level slog.Level, msg string, attrs ...slog.Attr) {
+	ctx := context.WithValue(context.Background(), internalKey{}, true)
+	if !Logger.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(now(), level, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = Logger.Handler().Handle(ctx, r)
+}
+
+//
+// breadcrumb() logs a record of slogf whatever the level, for the final records of a program that
+// must not be filtered out, like the shutdown ones.
+func breadcrumb(level slog.Level, msg string, args ...any) {
+	ctx := context.WithValue(context.Background(), internalKey{}, true)
+	r := slog.NewRecord(now(), level, msg, 0)
+	r.Add(args...)
+	_ = Logger.Handler().Handle(ctx, r)
+}
+
+type internalKey struct{}
+
+//
+// internal() tells whether ctx is the one of a record of slogf itself, like the notices, which
+// budgets and summaries leave alone.
+func internal(ctx context.Context) bool {
+	on, _ := ctx.Value(internalKey{}).(bool)
+	return on
+}
+
+//
+// contextAttrs() returns the attrs slogf adds to a record from the goroutine and the context.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	attrs := goroutineFields()
+	attrs = append(attrs, traceAttrs(ctx)...)
+	attrs = append(attrs, requestIDAttrs(ctx)...)
+	attrs = append(attrs, baggageAttrs(ctx)...)
+	attrs = append(attrs, extractedAttrs(ctx)...)
+	return attrs
+}
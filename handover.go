@@ -0,0 +1,66 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+//
+// Environment variable telling a re-executed child the descriptor of the inherited output.
+const handoverEnv = "SLOGF_OUTPUT_FD"
+
+//
+// HandoverOutput() passes the log output of the process, a file or socket, to cmd, a re-executed
+// copy of the program in a hot upgrade, so the child continues the same stream with
+// WithInheritedOutput(). The output may be an *os.File or a *LogFile, e.g. the one of the config.
+// The buffered records are flushed and a handover marker record is written first, so none is
+// lost. Both processes write whole records, so they don't interleave within a record while both
+// run. Call it before starting cmd.
+func HandoverOutput(cmd *exec.Cmd) error {
+	outputMu.Lock()
+	var f *os.File
+	switch o := output.(type) {
+	case *os.File:
+		f = o
+	case *LogFile:
+		f = o.File()
+	}
+	outputMu.Unlock()
+	if f == nil {
+		return fmt.Errorf("slogf: the output is not a file or socket, it can't be handed over")
+	}
+	// The child's descriptors are 0, 1, 2, then the extra files.
+	fd := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, handoverEnv+"="+strconv.Itoa(fd))
+	notice(slog.LevelInfo, "slogf: output handed over", slog.String("cmd", cmd.Path), slog.Int("fd", fd))
+	return closeSinks(false)
+}
+
+//
+// WithInheritedOutput() writes to the output handed over by the parent process with
+// HandoverOutput(), with an inheritance marker record, or keeps the output of the other options
+// when there is none.
+func WithInheritedOutput() Option {
+	return func(s *settings) {
+		v := os.Getenv(handoverEnv)
+		if v == "" {
+			return
+		}
+		fd, err := strconv.Atoi(v)
+		if err != nil || fd < 3 {
+			s.errs = append(s.errs, fmt.Errorf("slogf: %s=%q is not an inherited descriptor", handoverEnv, v))
+			return
+		}
+		s.output = os.NewFile(uintptr(fd), "slogf-inherited")
+		s.inherited = true
+		// Children of the child don't inherit it implicitly.
+		os.Unsetenv(handoverEnv)
+	}
+}
@@ -0,0 +1,43 @@
+package slogf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sync/atomic"
+)
+
+var (
+	newHash atomic.Pointer[func() hash.Hash]
+)
+
+//
+// SetHash() selects the hash algorithm of slogf's fingerprints and keys, e.g. error fingerprints.
+// The default crypto/sha256 suits FIPS-restricted deployments. nil restores it.
+// E.g. SetHash(sha512.New)
+func SetHash(h func() hash.Hash) {
+	if h == nil {
+		newHash.Store(nil)
+		return
+	}
+	newHash.Store(&h)
+}
+
+//
+// hashSum() returns the first 8 bytes, in hex, of the hash of parts.
+func hashSum(parts ...[]byte) string {
+	var h hash.Hash
+	if f := newHash.Load(); f != nil {
+		h = (*f)()
+	} else {
+		h = sha256.New()
+	}
+	for _, p := range parts {
+		h.Write(p)
+	}
+	sum := h.Sum(nil)
+	if len(sum) > 8 {
+		sum = sum[:8]
+	}
+	return hex.EncodeToString(sum)
+}
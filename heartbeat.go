@@ -0,0 +1,50 @@
+package slogf
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+//
+// Interval of StartHeartbeat(), StartRuntimeStats(), StartErrorSummary() and Watchdog() when the one
+// given isn't positive.
+const defaultHeartbeatInterval = time.Minute
+
+//
+// StartHeartbeat() emits a compact INFO record every interval with the process uptime, RuntimeStats()
+// (memory, goroutines) and log pipeline counters, so a silent but alive process can be told apart from
+// a dead one in centralized logs. The interval is a minute when it isn't positive. Call the returned
+// function to stop it.
+func StartHeartbeat(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	t := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				heartbeat()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Stop()
+			close(done)
+		})
+	}
+}
+
+func heartbeat() {
+	notice(slog.LevelInfo, "slogf: heartbeat",
+		slog.Duration("uptime", time.Since(startTime).Round(time.Second)),
+		RuntimeStats(),
+		pipelineStats(),
+	)
+}
@@ -0,0 +1,198 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//
+// HoneycombOptions configure a HoneycombHandler.
+type HoneycombOptions struct {
+	// APIKey of the Honeycomb environment.
+	APIKey string
+	// Dataset the events go to.
+	Dataset string
+	// URL of the API, https://api.honeycomb.io when empty, e.g. https://api.eu1.honeycomb.io for EU.
+	URL string
+	// Sample returns the sample rate of an event from its fields, 1 in rate is kept and sent with its
+	// rate so Honeycomb weighs it. nil or a rate below 2 keeps every event.
+	// E.g. keep all errors but 1 in 20 successful requests.
+	Sample func(fields map[string]any) uint
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Events per request, 100 when 0. Requests also happen every FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	Client        *http.Client
+	// OnError receives send failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+type honeycombEvent struct {
+	Time       string         `json:"time"`
+	SampleRate uint           `json:"samplerate,omitempty"`
+	Data       map[string]any `json:"data"`
+}
+
+//
+// HoneycombHandler sends records as wide events to the Honeycomb events API, flattening groups to
+// dotted columns, e.g. the canonical log lines of CanonicalMiddleware(). trace_id and span_id become
+// trace.trace_id and trace.parent_id, also within groups, linking the events to the traces.
+type HoneycombHandler struct {
+	opts  *HoneycombOptions
+	url   string
+	batch *batcher[honeycombEvent]
+	// fields are the flattened attrs from WithAttrs(), prefix the dotted path of the groups.
+	fields map[string]any
+	prefix string
+}
+
+//
+// NewHoneycombHandler() starts a HoneycombHandler. Add it with AddSink() and Close() it on shutdown.
+func NewHoneycombHandler(opts HoneycombOptions) (*HoneycombHandler, error) {
+	if opts.APIKey == "" {
+		return nil, errors.New("honeycomb: APIKey is required")
+	}
+	if opts.Dataset == "" {
+		return nil, errors.New("honeycomb: Dataset is required")
+	}
+	if opts.URL == "" {
+		opts.URL = "https://api.honeycomb.io"
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	h := &HoneycombHandler{opts: &opts, url: opts.URL + "/1/batch/" + url.PathEscape(opts.Dataset)}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.send, opts.OnError)
+	return h, nil
+}
+
+func (h *HoneycombHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+func (h *HoneycombHandler) Handle(ctx context.Context, r slog.Record) error {
+	data := make(map[string]any, len(h.fields)+r.NumAttrs()+3)
+	for k, v := range h.fields {
+		data[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(data, h.prefix, a)
+		return true
+	})
+	linkTrace(data, TraceIDKey, "trace.trace_id")
+	linkTrace(data, SpanIDKey, "trace.parent_id")
+	data[slog.MessageKey] = r.Message
+	data[slog.LevelKey] = levelLabel(r.Level)
+	if r.PC != 0 {
+		data[slog.SourceKey] = sourceOf(r.PC)
+	}
+
+	var rate uint
+	if h.opts.Sample != nil {
+		if rate = h.opts.Sample(data); rate > 1 && rand.Intn(int(rate)) != 0 {
+			return nil
+		}
+	}
+	h.batch.add(honeycombEvent{Time: r.Time.Format(time.RFC3339Nano), SampleRate: rate, Data: data})
+	return nil
+}
+
+//
+// linkTrace() renames the key of a trace id to the column linking the event to the trace. The
+// top-level one is taken first, then one in a group, e.g. http.trace_id, the others are left.
+func linkTrace(data map[string]any, key, column string) {
+	if _, set := data[column]; set {
+		return
+	}
+	k := key
+	if _, ok := data[key]; !ok {
+		k = ""
+		for name := range data {
+			if strings.HasSuffix(name, "."+key) && (k == "" || name < k) {
+				k = name
+			}
+		}
+		if k == "" {
+			return
+		}
+	}
+	data[column] = data[k]
+	delete(data, k)
+}
+
+func (h *HoneycombHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(map[string]any, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		flattenAttr(fields, h.prefix, a)
+	}
+	return &HoneycombHandler{opts: h.opts, url: h.url, batch: h.batch, fields: fields, prefix: h.prefix}
+}
+
+func (h *HoneycombHandler) WithGroup(name string) slog.Handler {
+	return &HoneycombHandler{opts: h.opts, url: h.url, batch: h.batch, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+//
+// Flush() sends the queued events.
+func (h *HoneycombHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after sending the queued events.
+func (h *HoneycombHandler) Close() error {
+	return h.batch.Close()
+}
+
+func (h *HoneycombHandler) send(events []honeycombEvent) error {
+	stampBatch(events, func(e *honeycombEvent, at time.Time) { e.Data["emitted_at"] = emittedValue(at) })
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", h.opts.APIKey)
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("honeycomb batch: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("honeycomb batch: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	// Every event has its own status, e.g. 400 for a malformed one.
+	var statuses []struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	if json.Unmarshal(msg, &statuses) == nil {
+		for _, s := range statuses {
+			if s.Status/100 != 2 {
+				return fmt.Errorf("honeycomb batch: event %d: %s", s.Status, s.Error)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//
+// Key of the message id replacing an interned message.
+const MsgIDKey = "msg_id"
+
+//
+// InternOptions configure InternMessages().
+type InternOptions struct {
+	// A message gets an id once seen MinCount times, 100 when 0.
+	MinCount int
+	// At most MaxEntries messages are tracked and interned, 1024 when 0.
+	MaxEntries int
+	// The dictionary record is emitted every DictionaryInterval, 1 minute when 0.
+	DictionaryInterval time.Duration
+}
+
+type interner struct {
+	opts   InternOptions
+	mu     sync.Mutex
+	counts map[string]int
+	ids    map[string]int
+	dict   []string
+	next   slog.Handler
+	done   chan struct{}
+	once   sync.Once
+}
+
+//
+// InternHandler is the sink wrapper of InternMessages().
+type InternHandler struct {
+	in   *interner
+	next slog.Handler
+}
+
+//
+// InternMessages() wraps a sink, typically carrying high-volume telemetry-style records, so frequently
+// repeated messages are replaced by a numeric msg_id with an empty msg. A "slogf: message dictionary"
+// record mapping the ids to the messages is emitted every DictionaryInterval, so consumers can
+// decode the stream. Close() the returned handler to stop it.
+func InternMessages(h slog.Handler, opts InternOptions) *InternHandler {
+	if opts.MinCount <= 0 {
+		opts.MinCount = 100
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1024
+	}
+	if opts.DictionaryInterval <= 0 {
+		opts.DictionaryInterval = time.Minute
+	}
+	in := &interner{opts: opts, counts: map[string]int{}, ids: map[string]int{}, next: h, done: make(chan struct{})}
+	go in.run()
+	return &InternHandler{in: in, next: h}
+}
+
+func (h *InternHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *InternHandler) Handle(ctx context.Context, r slog.Record) error {
+	id, ok := h.in.intern(r.Message)
+	if !ok {
+		return h.next.Handle(ctx, r)
+	}
+	interned := slog.NewRecord(r.Time, r.Level, "", r.PC)
+	interned.AddAttrs(slog.Int(MsgIDKey, id))
+	r.Attrs(func(a slog.Attr) bool {
+		interned.AddAttrs(a)
+		return true
+	})
+	return h.next.Handle(ctx, interned)
+}
+
+func (h *InternHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &InternHandler{in: h.in, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *InternHandler) WithGroup(name string) slog.Handler {
+	return &InternHandler{in: h.in, next: h.next.WithGroup(name)}
+}
+
+//
+// Close() stops the dictionary records after emitting a last one.
+func (h *InternHandler) Close() error {
+	h.in.once.Do(func() { close(h.in.done) })
+	return h.in.emitDictionary()
+}
+
+func (h *InternHandler) unwrap() slog.Handler {
+	return h.next
+}
+
+//
+// intern() counts msg and returns its id once it has one.
+func (in *interner) intern(msg string) (int, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if id, ok := in.ids[msg]; ok {
+		return id, true
+	}
+	c, tracked := in.counts[msg]
+	if !tracked && len(in.counts) >= in.opts.MaxEntries {
+		return 0, false
+	}
+	c++
+	if c < in.opts.MinCount {
+		in.counts[msg] = c
+		return 0, false
+	}
+	in.dict = append(in.dict, msg)
+	id := len(in.dict)
+	in.ids[msg] = id
+	return id, true
+}
+
+func (in *interner) run() {
+	t := time.NewTicker(in.opts.DictionaryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := in.emitDictionary(); err != nil {
+				sinkErrors.Add(1)
+			}
+		case <-in.done:
+			return
+		}
+	}
+}
+
+func (in *interner) emitDictionary() error {
+	in.mu.Lock()
+	attrs := make([]slog.Attr, len(in.dict))
+	for i, msg := range in.dict {
+		attrs[i] = slog.String(strconv.Itoa(i+1), msg)
+	}
+	in.mu.Unlock()
+	if len(attrs) == 0 {
+		return nil
+	}
+	r := slog.NewRecord(now(), slog.LevelInfo, "slogf: message dictionary", 0)
+	r.AddAttrs(slog.Attr{Key: "messages", Value: slog.GroupValue(attrs...)})
+	return in.next.Handle(context.Background(), r)
+}
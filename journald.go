@@ -0,0 +1,210 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Syslog priorities of the journal, for JournaldOptions.Priority.
+const (
+	JournalEmerg = iota
+	JournalAlert
+	JournalCrit
+	JournalErr
+	JournalWarning
+	JournalNotice
+	JournalInfo
+	JournalDebug
+)
+
+//
+// JournaldOptions configure a JournaldHandler.
+type JournaldOptions struct {
+	// Socket of journald, /run/systemd/journal/socket when empty.
+	Socket string
+	// Identifier is the SYSLOG_IDENTIFIER of the entries, the executable name when empty.
+	Identifier string
+	// Priority maps a level to a syslog priority, DEBUG to debug, INFO to info, WARN to warning,
+	// ERROR to err and FATAL to crit when nil. E.g. map FATAL to JournalEmerg to page on it.
+	Priority func(level slog.Level) int
+	// Field tells whether the attr of a key, dotted in groups, becomes an uppercase journal field,
+	// http.path becoming HTTP_PATH. The others are folded into MESSAGE as key=value so they show in
+	// journalctl's default output. All attrs become fields when nil.
+	Field func(key string) bool
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+}
+
+//
+// JournaldHandler writes records to the systemd journal with its native protocol. The source goes
+// to CODE_FILE, CODE_LINE and CODE_FUNC.
+type JournaldHandler struct {
+	opts *JournaldOptions
+	conn *net.UnixConn
+	// fields are the flattened attrs from WithAttrs(), prefix the dotted path of the groups.
+	fields map[string]any
+	prefix string
+}
+
+//
+// NewJournaldHandler() connects to journald. Add it with AddSink() and Close() it on shutdown.
+func NewJournaldHandler(opts JournaldOptions) (*JournaldHandler, error) {
+	if opts.Socket == "" {
+		opts.Socket = "/run/systemd/journal/socket"
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if opts.Priority == nil {
+		opts.Priority = journalPriority
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: opts.Socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	return &JournaldHandler{opts: &opts, conn: conn}, nil
+}
+
+//
+// journalPriority() is the default mapping of JournaldOptions.Priority.
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= LevelFatal:
+		return JournalCrit
+	case level >= slog.LevelError:
+		return JournalErr
+	case level >= slog.LevelWarn:
+		return JournalWarning
+	case level >= slog.LevelInfo:
+		return JournalInfo
+	}
+	return JournalDebug
+}
+
+func (h *JournaldHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+func (h *JournaldHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(attrs, h.prefix, a)
+		return true
+	})
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	var msg strings.Builder
+	msg.WriteString(r.Message)
+	for _, k := range keys {
+		if h.opts.Field == nil || h.opts.Field(k) {
+			if name := journalField(k); name != "" && !journalReserved[name] {
+				appendJournalField(&b, name, fmt.Sprint(attrs[k]))
+				continue
+			}
+		}
+		v := fmt.Sprint(attrs[k])
+		if strings.ContainsAny(v, " =\"\n") {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(&msg, " %s=%s", k, v)
+	}
+	appendJournalField(&b, "MESSAGE", msg.String())
+	appendJournalField(&b, "PRIORITY", strconv.Itoa(h.opts.Priority(r.Level)))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", h.opts.Identifier)
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		appendJournalField(&b, "CODE_FILE", f.File)
+		appendJournalField(&b, "CODE_LINE", strconv.Itoa(f.Line))
+		appendJournalField(&b, "CODE_FUNC", f.Function)
+	}
+	if _, err := h.conn.Write(b.Bytes()); err != nil {
+		sinkErrors.Add(1)
+		return fmt.Errorf("journald: %w", err)
+	}
+	return nil
+}
+
+func (h *JournaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(map[string]any, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		flattenAttr(fields, h.prefix, a)
+	}
+	return &JournaldHandler{opts: h.opts, conn: h.conn, fields: fields, prefix: h.prefix}
+}
+
+func (h *JournaldHandler) WithGroup(name string) slog.Handler {
+	return &JournaldHandler{opts: h.opts, conn: h.conn, fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+//
+// Close() closes the connection to journald.
+func (h *JournaldHandler) Close() error {
+	return h.conn.Close()
+}
+
+//
+// journalReserved are the fields set by JournaldHandler itself, the attrs named so are folded.
+var journalReserved = map[string]bool{
+	"MESSAGE": true, "PRIORITY": true, "SYSLOG_IDENTIFIER": true, "CODE_FILE": true, "CODE_LINE": true, "CODE_FUNC": true,
+}
+
+//
+// journalField() returns the journal field name of an attr key, "" when it has no letter or digit.
+// Names are uppercase letters, digits and underscores, not starting with an underscore, which is
+// for the fields journald adds itself.
+func journalField(key string) string {
+	name := make([]byte, 0, len(key))
+	for _, c := range []byte(strings.ToUpper(key)) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			name = append(name, c)
+		} else if len(name) > 0 {
+			name = append(name, '_')
+		}
+	}
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte("F_"), name...)
+	}
+	return string(name)
+}
+
+//
+// appendJournalField() appends a field in the native protocol of journald, with the binary framing
+// for values holding newlines.
+func appendJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
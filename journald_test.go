@@ -0,0 +1,145 @@
+package slogf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseJournal parses the fields of an entry written with appendJournalField().
+func parseJournal(b []byte) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		if i < 0 {
+			return nil, errors.New("unterminated field")
+		}
+		name := string(b[:i])
+		if b[i] == '=' {
+			j := bytes.IndexByte(b[i:], '\n')
+			if j < 0 {
+				return nil, errors.New("unterminated value")
+			}
+			fields[name], b = string(b[i+1:i+j]), b[i+j+1:]
+			continue
+		}
+		b = b[i+1:]
+		if len(b) < 8 {
+			return nil, errors.New("short size")
+		}
+		n := binary.LittleEndian.Uint64(b)
+		b = b[8:]
+		if uint64(len(b)) < n+1 || b[n] != '\n' {
+			return nil, errors.New("bad binary value")
+		}
+		fields[name], b = string(b[:n]), b[n+1:]
+	}
+	return fields, nil
+}
+
+func TestAppendJournalField(t *testing.T) {
+	tests := []struct {
+		name, value string
+		want        string
+	}{
+		{"MESSAGE", "hello", "MESSAGE=hello\n"},
+		{"EMPTY", "", "EMPTY=\n"},
+		{"EQUALS", "a=b=c", "EQUALS=a=b=c\n"},
+		{"STACK", "a\nb", "STACK\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n"},
+		{"NL", "\n", "NL\n\x01\x00\x00\x00\x00\x00\x00\x00\n\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		appendJournalField(&b, tt.name, tt.value)
+		if b.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, b.String(), tt.want)
+		}
+	}
+
+	var b bytes.Buffer
+	for _, tt := range tests {
+		appendJournalField(&b, tt.name, tt.value)
+	}
+	fields, err := parseJournal(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if fields[tt.name] != tt.value {
+			t.Errorf("%s: parsed %q, want %q", tt.name, fields[tt.name], tt.value)
+		}
+	}
+}
+
+func TestJournalField(t *testing.T) {
+	tests := map[string]string{
+		"path":      "PATH",
+		"http.path": "HTTP_PATH",
+		"req-id":    "REQ_ID",
+		"_private":  "PRIVATE",
+		"2fa":       "F_2FA",
+		"...":       "",
+	}
+	for key, want := range tests {
+		if got := journalField(key); got != want {
+			t.Errorf("journalField(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestJournaldHandler(t *testing.T) {
+	// A short directory, the path of a unix socket is limited to about 100 bytes.
+	dir, err := os.MkdirTemp("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+
+	h, err := NewJournaldHandler(JournaldOptions{
+		Socket:     socket,
+		Identifier: "test",
+		Field:      func(key string) bool { return key != "http.user" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	slog.New(h).WithGroup("http").Error("failed", "path", "/x", "stack", "a\nb", "user", "bob smith", "message", "m")
+
+	b := make([]byte, 65536)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := parseJournal(b[:n])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"MESSAGE":           `failed http.user="bob smith"`,
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "test",
+		"HTTP_PATH":         "/x",
+		"HTTP_STACK":        "a\nb",
+		"HTTP_MESSAGE":      "m",
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("%s: got %q, want %q", name, fields[name], value)
+		}
+	}
+	if fields["CODE_FILE"] == "" || fields["CODE_LINE"] == "" {
+		t.Errorf("no source in %q", fields)
+	}
+}
@@ -0,0 +1,87 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+var (
+	// renamedKeys are the names of WithKeyNames(), reserved as the built-in keys are.
+	renamedKeys atomic.Pointer[map[string]bool]
+)
+
+//
+// KeyNames are the names of the built-in keys of WithKeyNames(), the empty ones keeping theirs.
+type KeyNames struct {
+	Time    string // time
+	Level   string // level
+	Message string // msg
+	Source  string // source
+}
+
+//
+// WithKeyNames() renames the built-in time, level, msg and source keys in the output, e.g. for a
+// SIEM schema mandating its own field names. The replace functions of WithReplaceAttr() still see
+// the built-in ones. Top-level attrs of the callers using the built-in keys are renamed with them,
+// and the ones using the new names collide with them, RenameReservedKeys() keeps both apart.
+// E.g. WithKeyNames(KeyNames{Time: "timestamp", Level: "severity", Message: "message"})
+func WithKeyNames(names KeyNames) Option {
+	return func(s *settings) {
+		renames := map[string]string{}
+		for key, name := range map[string]string{slog.TimeKey: names.Time, slog.LevelKey: names.Level, slog.MessageKey: names.Message, slog.SourceKey: names.Source} {
+			if name != "" && name != key {
+				renames[key] = name
+			}
+		}
+		if err := checkKeyNames(renames); err != nil {
+			s.errs = append(s.errs, err)
+			return
+		}
+		s.keyNames = renames
+	}
+}
+
+//
+// checkKeyNames() reports the renames giving two keys the same name.
+func checkKeyNames(renames map[string]string) error {
+	final := map[string]string{}
+	for _, key := range []string{slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey} {
+		name := key
+		if n, ok := renames[key]; ok {
+			name = n
+		}
+		if other, dup := final[name]; dup {
+			return fmt.Errorf("slogf: key names: %s and %s both named %q", other, key, name)
+		}
+		final[name] = key
+	}
+	return nil
+}
+
+//
+// setRenamedKeys() reserves the new names of renames, see isReservedKey().
+func setRenamedKeys(renames map[string]string) {
+	if len(renames) == 0 {
+		renamedKeys.Store(nil)
+		return
+	}
+	names := make(map[string]bool, len(renames))
+	for _, name := range renames {
+		names[name] = true
+	}
+	renamedKeys.Store(&names)
+}
+
+//
+// renameKeys() returns the replace function renaming the top-level built-in keys.
+func renameKeys(renames map[string]string) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			if name, ok := renames[a.Key]; ok {
+				a.Key = name
+			}
+		}
+		return a
+	}
+}
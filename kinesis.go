@@ -0,0 +1,163 @@
+package slogf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+//
+// KinesisRecord is an entry of a PutRecords or PutRecordBatch call.
+type KinesisRecord struct {
+	Data []byte
+	// PartitionKey picks the shard of a Kinesis data stream, Firehose ignores it.
+	PartitionKey string
+}
+
+//
+// KinesisOptions configure a KinesisHandler.
+type KinesisOptions struct {
+	// Put sends a batch, wrapping the PutRecords call of a Kinesis client or the PutRecordBatch one
+	// of a Firehose client, so slogf doesn't depend on the AWS SDK. A batch has at most 500 records.
+	Put func(ctx context.Context, records []KinesisRecord) error
+	// PartitionKey is the key of the attr whose value is the partition key, e.g. "tenant_id", so the
+	// records of a tenant stay in order on one shard. Records without it get a random one.
+	PartitionKey string
+	// Firehose ends every record with a newline, Firehose concatenating them as they are into S3.
+	Firehose bool
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Records per call, 500 when 0 or above. Calls also happen every FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	// OnError receives put failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+//
+// Batch limits of PutRecords, PutRecordBatch being a bit lower in bytes.
+const (
+	kinesisMaxRecords = 500
+	kinesisMaxBytes   = 4 << 20
+)
+
+//
+// KinesisHandler sends records as JSON lines to a Kinesis data stream or a Firehose delivery
+// stream, for AWS pipelines that don't go through CloudWatch Logs.
+type KinesisHandler struct {
+	opts  *KinesisOptions
+	enc   *recordEncoder
+	batch *batcher[KinesisRecord]
+	// key is the partition key from WithAttrs(), "" when none.
+	key string
+	// grouped tells attrs are under a group, no longer the top-level partition key.
+	grouped bool
+}
+
+//
+// NewKinesisHandler() starts a KinesisHandler. Add it with AddSink() and Close() it on shutdown.
+// E.g.
+//
+//	h, err := NewKinesisHandler(KinesisOptions{PartitionKey: "tenant_id", Put: func(ctx context.Context, records []KinesisRecord) error {
+//		entries := make([]types.PutRecordsRequestEntry, len(records))
+//		for i, r := range records {
+//			entries[i] = types.PutRecordsRequestEntry{Data: r.Data, PartitionKey: aws.String(r.PartitionKey)}
+//		}
+//		_, err := client.PutRecords(ctx, &kinesis.PutRecordsInput{StreamName: aws.String("logs"), Records: entries})
+//		return err
+//	}})
+func NewKinesisHandler(opts KinesisOptions) (*KinesisHandler, error) {
+	if opts.Put == nil {
+		return nil, errors.New("kinesis: Put is required")
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > kinesisMaxRecords {
+		opts.BatchSize = kinesisMaxRecords
+	}
+	h := &KinesisHandler{opts: &opts, enc: newJSONEncoder(&slog.HandlerOptions{AddSource: true, Level: opts.Level, ReplaceAttr: replaceAttr})}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.put, opts.OnError)
+	return h, nil
+}
+
+func (h *KinesisHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enc.h.Enabled(ctx, level)
+}
+
+func (h *KinesisHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, err := h.enc.encode(ctx, r)
+	if err != nil {
+		return err
+	}
+	if h.opts.Firehose {
+		line = append(line, '\n')
+	}
+	key := h.key
+	if h.opts.PartitionKey != "" && !h.grouped {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == h.opts.PartitionKey {
+				key = a.Value.Resolve().String()
+			}
+			return true
+		})
+	}
+	if key == "" {
+		key = newID()
+	}
+	h.batch.add(KinesisRecord{Data: line, PartitionKey: key})
+	return nil
+}
+
+func (h *KinesisHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.enc = h.enc.WithAttrs(attrs)
+	if h.opts.PartitionKey != "" && !h.grouped {
+		for _, a := range attrs {
+			if a.Key == h.opts.PartitionKey {
+				c.key = a.Value.Resolve().String()
+			}
+		}
+	}
+	return &c
+}
+
+func (h *KinesisHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.enc = h.enc.WithGroup(name)
+	c.grouped = true
+	return &c
+}
+
+//
+// Flush() sends the queued records.
+func (h *KinesisHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after sending the queued records.
+func (h *KinesisHandler) Close() error {
+	return h.batch.Close()
+}
+
+//
+// put() sends records in calls within the size limit of a batch.
+func (h *KinesisHandler) put(records []KinesisRecord) error {
+	stampBatch(records, func(r *KinesisRecord, at time.Time) { r.Data = stampLine(r.Data, at) })
+	var errs []error
+	for len(records) > 0 {
+		n, size := 0, 0
+		for n < len(records) {
+			size += len(records[n].Data) + len(records[n].PartitionKey)
+			if n > 0 && size > kinesisMaxBytes {
+				break
+			}
+			n++
+		}
+		if err := h.opts.Put(context.Background(), records[:n]); err != nil {
+			errs = append(errs, fmt.Errorf("kinesis put: %w", err))
+		}
+		records = records[n:]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,38 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type lastErr struct {
+	msg string
+	at  time.Time
+}
+
+var (
+	lastError atomic.Pointer[lastErr]
+)
+
+//
+// LastError() returns the message and time of the most recent ERROR or FATAL record, so /healthz
+// handlers can report it and its age without parsing logs. ok is false until there is one.
+// Records held back by Suppress() or StartErrorSummary() still count, the error_summary records
+// don't.
+func LastError() (msg string, at time.Time, ok bool) {
+	e := lastError.Load()
+	if e == nil {
+		return "", time.Time{}, false
+	}
+	return e.msg, e.at, true
+}
+
+//
+// recordError() keeps r as the last error, unless it's a record of slogf itself like error_summary.
+func recordError(ctx context.Context, r slog.Record) {
+	if r.Level >= slog.LevelError && !internal(ctx) {
+		lastError.Store(&lastErr{msg: r.Message, at: r.Time})
+	}
+}
@@ -0,0 +1,30 @@
+package slogf
+
+import (
+	"log/slog"
+	"sync"
+)
+
+type lazyGroup struct {
+	once  sync.Once
+	fn    func() []slog.Attr
+	attrs []slog.Attr
+}
+
+//
+// LazyGroup() returns a group whose attrs are computed by fn only when the record is actually
+// emitted, so expensive fields cost nothing on records below the level or suppressed.
+// fn is called at most once, however many sinks the record goes to.
+// E.g. Debug("Tick.", LazyGroup("queues", queueDepths))
+func LazyGroup(name string, fn func() []slog.Attr) slog.Attr {
+	return slog.Any(name, &lazyGroup{fn: fn})
+}
+
+//
+// LogValue() implements slog.LogValuer.
+func (g *lazyGroup) LogValue() slog.Value {
+	g.once.Do(func() {
+		g.attrs = g.fn()
+	})
+	return slog.GroupValue(g.attrs...)
+}
@@ -0,0 +1,89 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+//
+// Level formats of WithLevelFormat().
+const (
+	// LevelFormatUpper is slog's, INFO, WARN+2, with FATAL, the default.
+	LevelFormatUpper = "upper"
+	// LevelFormatLower is the lowercase one, info, warn+2, fatal.
+	LevelFormatLower = "lower"
+	// LevelFormatNumeric is the number of the slog level, 0 for INFO, 12 for FATAL.
+	LevelFormatNumeric = "numeric"
+)
+
+//
+// levelRender is how the level of the records is written, set by Init().
+type levelRender struct {
+	format string
+	labels map[slog.Level]string
+}
+
+var (
+	levelRendering atomic.Pointer[levelRender]
+)
+
+//
+// WithLevelFormat() sets how the level of the records is written, one of the LevelFormat*
+// constants, as the backends expect it differently.
+// E.g. WithLevelFormat(LevelFormatLower)
+func WithLevelFormat(format string) Option {
+	return func(s *settings) {
+		f := strings.ToLower(format)
+		switch f {
+		case LevelFormatUpper, LevelFormatLower, LevelFormatNumeric:
+			s.levelFormat = f
+		case "":
+			s.levelFormat = LevelFormatUpper
+		default:
+			s.errs = append(s.errs, fmt.Errorf("slogf: unknown level format %q, want upper, lower or numeric", format))
+		}
+	}
+}
+
+//
+// WithLevelLabels() writes the levels of labels with them, the others in the level format. Every
+// call adds to the labels.
+// E.g. WithLevelLabels(map[slog.Level]string{slog.LevelWarn: "WARNING", LevelFatal: "CRITICAL"})
+func WithLevelLabels(labels map[slog.Level]string) Option {
+	return func(s *settings) {
+		merged := make(map[slog.Level]string, len(s.levelLabels)+len(labels))
+		for level, label := range s.levelLabels {
+			merged[level] = label
+		}
+		for level, label := range labels {
+			if label == "" {
+				s.errs = append(s.errs, fmt.Errorf("slogf: empty label for level %s", levelLabel(level)))
+				continue
+			}
+			merged[level] = label
+		}
+		s.levelLabels = merged
+	}
+}
+
+//
+// levelValue() returns the level attr value of level, as set by WithLevelFormat() and
+// WithLevelLabels().
+func levelValue(level slog.Level) slog.Value {
+	lr := levelRendering.Load()
+	if lr == nil {
+		return slog.StringValue(levelLabel(level))
+	}
+	if label, ok := lr.labels[level]; ok {
+		return slog.StringValue(label)
+	}
+	switch lr.format {
+	case LevelFormatLower:
+		return slog.StringValue(strings.ToLower(levelLabel(level)))
+	case LevelFormatNumeric:
+		return slog.Int64Value(int64(level))
+	}
+	return slog.StringValue(levelLabel(level))
+}
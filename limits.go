@@ -0,0 +1,100 @@
+package slogf
+
+import (
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+type limits struct {
+	maxLen   int
+	maxDepth int
+}
+
+var (
+	limit atomic.Pointer[limits]
+)
+
+//
+// TooDeep replaces the groups nested deeper than the depth limit.
+const TooDeep = "[too deep]"
+
+//
+// SetLimits() bounds what a record may carry, so logged user input can't blow up the output or the
+// handlers: messages and string values longer than maxLen bytes are truncated, at a rune boundary,
+// and groups nested deeper than maxDepth are replaced by "[too deep]". 0 means no limit.
+// With a depth limit, slog.LogValuer values are resolved once up front, except the Secret() ones,
+// so a LogValuer returning itself in a group can't recurse forever.
+// E.g. SetLimits(64<<10, 32)
+func SetLimits(maxLen, maxDepth int) {
+	if maxLen <= 0 && maxDepth <= 0 {
+		limit.Store(nil)
+		return
+	}
+	limit.Store(&limits{maxLen: maxLen, maxDepth: maxDepth})
+}
+
+//
+// limitRecord() returns r within the limits, or r itself when there are none.
+func limitRecord(r slog.Record) slog.Record {
+	l := limit.Load()
+	if l == nil {
+		return r
+	}
+	limited := slog.NewRecord(r.Time, r.Level, l.truncate(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		limited.AddAttrs(l.attr(a, 1))
+		return true
+	})
+	return limited
+}
+
+//
+// limitAttrs() returns attrs within the limits.
+func limitAttrs(attrs []slog.Attr) []slog.Attr {
+	l := limit.Load()
+	if l == nil {
+		return attrs
+	}
+	limited := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		limited[i] = l.attr(a, 1)
+	}
+	return limited
+}
+
+func (l *limits) attr(a slog.Attr, depth int) slog.Attr {
+	v := a.Value
+	if l.maxDepth > 0 && v.Kind() == slog.KindLogValuer {
+		if _, ok := v.Any().(secret); !ok {
+			v = v.Resolve()
+		}
+	}
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, l.truncate(v.String()))
+	case slog.KindGroup:
+		if l.maxDepth > 0 && depth > l.maxDepth {
+			return slog.String(a.Key, TooDeep)
+		}
+		group := v.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, g := range group {
+			attrs[i] = l.attr(g, depth+1)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func (l *limits) truncate(s string) string {
+	if l.maxLen <= 0 || len(s) <= l.maxLen {
+		return s
+	}
+	cut := l.maxLen
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...[truncated " + strconv.Itoa(len(s)-cut) + " bytes]"
+}
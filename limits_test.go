@@ -0,0 +1,134 @@
+package slogf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// nested returns an attr holding depth groups, the innermost one holding value.
+func nested(key, value string, depth int) slog.Attr {
+	a := slog.String(key, value)
+	for i := 0; i < depth; i++ {
+		a = slog.Attr{Key: key, Value: slog.GroupValue(a)}
+	}
+	return a
+}
+
+// depthOf returns the number of groups nested in a.
+func depthOf(a slog.Attr) int {
+	if a.Value.Kind() != slog.KindGroup {
+		return 0
+	}
+	max := 0
+	for _, g := range a.Value.Group() {
+		if d := depthOf(g); d > max {
+			max = d
+		}
+	}
+	return max + 1
+}
+
+// checkLimited fails when a string of a is longer than maxLen, plus the truncation note, or was
+// valid UTF-8 and no longer is.
+func checkLimited(t *testing.T, s, in string, maxLen int) {
+	t.Helper()
+	if len(s) > maxLen+len("...[truncated 18446744073709551615 bytes]") {
+		t.Fatalf("%d bytes left of %d with a limit of %d", len(s), len(in), maxLen)
+	}
+	if utf8.ValidString(in) && !utf8.ValidString(s) {
+		t.Fatalf("truncation split a rune: %q", s)
+	}
+	if len(in) <= maxLen && s != in {
+		t.Fatalf("%q within the limit changed to %q", in, s)
+	}
+}
+
+func FuzzLimitRecord(f *testing.F) {
+	f.Add("msg", "key", "value", 8, 16, 4)
+	f.Add(strings.Repeat("é", 1000), "k", strings.Repeat("日本", 500), 100, 7, 2)
+	f.Add("\xff\xfe", "\x00", "a\nb\"c\\", 3, 1, 1)
+	f.Add("", "", "", 0, 0, 0)
+	f.Fuzz(func(t *testing.T, msg, key, value string, depth, maxLen, maxDepth int) {
+		depth, maxLen, maxDepth = depth&127, maxLen&1023, maxDepth&63
+		SetLimits(maxLen, maxDepth)
+		defer SetLimits(0, 0)
+
+		r := slog.NewRecord(now(), slog.LevelInfo, msg, 0)
+		r.AddAttrs(slog.String(key, value), nested(key, value, depth))
+		limited := limitRecord(r)
+		if maxLen > 0 {
+			checkLimited(t, limited.Message, msg, maxLen)
+		}
+		limited.Attrs(func(a slog.Attr) bool {
+			if maxDepth > 0 && depthOf(a) > maxDepth {
+				t.Fatalf("%d groups left with a limit of %d", depthOf(a), maxDepth)
+			}
+			for a.Value.Kind() == slog.KindGroup {
+				a = a.Value.Group()[0]
+			}
+			if maxLen > 0 && a.Value.String() != TooDeep {
+				checkLimited(t, a.Value.String(), value, maxLen)
+			}
+			return true
+		})
+
+		for _, format := range []string{"text", "json", "console"} {
+			var b bytes.Buffer
+			if err := Reinit(WithFormat(format), WithOutput(&b)); err != nil {
+				t.Fatal(err)
+			}
+			Logger.Info(msg, slog.String(key, value), nested(key, value, depth))
+			if format == "json" && !json.Valid(b.Bytes()) {
+				t.Fatalf("invalid JSON: %s", b.Bytes())
+			}
+		}
+	})
+}
+
+func FuzzLimitAttrs(f *testing.F) {
+	f.Add("key", "value", 10, 4, 2)
+	f.Add("k", strings.Repeat("x", 5000), 500, 100, 3)
+	f.Add("\xc3", "\xc3\xa9\xc3", 2, 1, 0)
+	f.Fuzz(func(t *testing.T, key, value string, n, maxLen, maxDepth int) {
+		n, maxLen, maxDepth = n&255, maxLen&1023, maxDepth&63
+		SetLimits(maxLen, maxDepth)
+		defer SetLimits(0, 0)
+
+		attrs := make([]slog.Attr, n)
+		for i := range attrs {
+			attrs[i] = nested(key, value, i%8)
+		}
+		limited := limitAttrs(attrs)
+		if len(limited) != n {
+			t.Fatalf("%d attrs left of %d", len(limited), n)
+		}
+		for _, a := range limited {
+			if maxDepth > 0 && depthOf(a) > maxDepth {
+				t.Fatalf("%d groups left with a limit of %d", depthOf(a), maxDepth)
+			}
+			for a.Value.Kind() == slog.KindGroup {
+				a = a.Value.Group()[0]
+			}
+			if maxLen > 0 && a.Value.String() != TooDeep {
+				checkLimited(t, a.Value.String(), value, maxLen)
+			}
+		}
+
+		var b bytes.Buffer
+		if err := Reinit(WithFormat("json"), WithOutput(&b)); err != nil {
+			t.Fatal(err)
+		}
+		args := make([]any, len(attrs))
+		for i, a := range attrs {
+			args[i] = a
+		}
+		Logger.With(args...).Info("with")
+		if !json.Valid(b.Bytes()) {
+			t.Fatalf("invalid JSON: %s", b.Bytes())
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package slogf
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+//
+// LogFile is a log file that only ever holds complete records: every record goes out in a single
+// write, appended, so a crash can't interleave or cut one. A write cut short, e.g. by a full disk,
+// is rolled back so the next record doesn't start in the middle of a line.
+type LogFile struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+//
+// OpenLogFile() opens path for appending, creating it if needed, e.g. for WithOutput(). It is how
+// the output file of the config is opened.
+func OpenLogFile(path string) (*LogFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &LogFile{f: f}, nil
+}
+
+//
+// Write() appends p, one or more complete records, in a single write.
+func (l *LogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, err := l.f.Write(p)
+	if err != nil && n > 0 && n < len(p) {
+		// Drop the partial record, the file ends where the previous one did.
+		if end, serr := l.f.Seek(0, io.SeekEnd); serr == nil {
+			_ = l.f.Truncate(end - int64(n))
+		}
+		n = 0
+	}
+	return n, err
+}
+
+//
+// Sync() commits the records written to stable storage.
+func (l *LogFile) Sync() error {
+	return l.f.Sync()
+}
+
+//
+// File() returns the underlying file, e.g. to hand it over to a child process.
+func (l *LogFile) File() *os.File {
+	return l.f
+}
+
+//
+// Close() closes the file.
+func (l *LogFile) Close() error {
+	return l.f.Close()
+}
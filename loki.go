@@ -0,0 +1,147 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//
+// LokiOptions configure a LokiHandler.
+type LokiOptions struct {
+	// URL of the Loki push API, e.g. http://loki:3100/loki/api/v1/push
+	URL string
+	// Stream labels, keep them few and low cardinality, e.g. {"service": "payments"}.
+	Labels map[string]string
+	// TenantID is sent as X-Scope-OrgID in multi-tenant setups.
+	TenantID string
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Records per push, 100 when 0. Pushes also happen every FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	Client        *http.Client
+	// OnError receives push failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+type lokiEntry struct {
+	ts       time.Time
+	line     []byte
+	metadata map[string]string
+}
+
+//
+// LokiHandler pushes records as JSON lines to Grafana Loki.
+// trace_id and span_id are sent as structured metadata following Grafana's conventions, so the
+// "logs to traces" links of Grafana Explore work out of the box without making them labels.
+type LokiHandler struct {
+	opts  *LokiOptions
+	enc   *recordEncoder
+	batch *batcher[lokiEntry]
+	// attrs from WithAttrs(), looked into for the structured metadata.
+	attrs []slog.Attr
+}
+
+//
+// NewLokiHandler() starts a LokiHandler. Add it with AddSink() and Close() it on shutdown.
+func NewLokiHandler(opts LokiOptions) *LokiHandler {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	h := &LokiHandler{opts: &opts, enc: newJSONEncoder(&slog.HandlerOptions{AddSource: true, Level: opts.Level, ReplaceAttr: replaceAttr})}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.push, opts.OnError)
+	return h
+}
+
+func (h *LokiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enc.h.Enabled(ctx, level)
+}
+
+func (h *LokiHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, err := h.enc.encode(ctx, r)
+	if err != nil {
+		return err
+	}
+	metadata := map[string]string{}
+	collect := func(a slog.Attr) bool {
+		if a.Key == TraceIDKey || a.Key == SpanIDKey {
+			metadata[a.Key] = a.Value.String()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		collect(a)
+	}
+	r.Attrs(collect)
+	h.batch.add(lokiEntry{ts: r.Time, line: line, metadata: metadata})
+	return nil
+}
+
+func (h *LokiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LokiHandler{opts: h.opts, enc: h.enc.WithAttrs(attrs), batch: h.batch, attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h *LokiHandler) WithGroup(name string) slog.Handler {
+	// Attrs under a group are no longer the top-level trace ids.
+	return &LokiHandler{opts: h.opts, enc: h.enc.WithGroup(name), batch: h.batch, attrs: h.attrs}
+}
+
+//
+// Flush() pushes the queued records.
+func (h *LokiHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after pushing the queued records.
+func (h *LokiHandler) Close() error {
+	return h.batch.Close()
+}
+
+func (h *LokiHandler) push(entries []lokiEntry) error {
+	stampBatch(entries, func(e *lokiEntry, at time.Time) { e.line = stampLine(e.line, at) })
+	values := make([][]any, len(entries))
+	for i, e := range entries {
+		v := []any{strconv.FormatInt(e.ts.UnixNano(), 10), string(e.line)}
+		if len(e.metadata) > 0 {
+			v = append(v, e.metadata)
+		}
+		values[i] = v
+	}
+	labels := h.opts.Labels
+	if len(labels) == 0 {
+		labels = map[string]string{"service_name": "unknown_service"}
+	}
+	body, err := json.Marshal(map[string]any{
+		"streams": []any{map[string]any{"stream": labels, "values": values}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.opts.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", h.opts.TenantID)
+	}
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("loki push: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("loki push: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
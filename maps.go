@@ -0,0 +1,60 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync/atomic"
+)
+
+var (
+	sortedMaps atomic.Bool
+)
+
+//
+// SetSortedMaps() makes map values render as groups with their keys in sorted order, so repeated
+// records are byte-identical for dedup and diffing in every sink, whatever its encoding.
+// E.g. Info("Quotas.", "quota", map[string]int{"b": 2, "a": 1}) gives quota.a=1 quota.b=2
+func SetSortedMaps(on bool) {
+	sortedMaps.Store(on)
+}
+
+//
+// sortRecordMaps() returns r with its map values as sorted groups, or r itself when off.
+func sortRecordMaps(r slog.Record) slog.Record {
+	if !sortedMaps.Load() {
+		return r
+	}
+	sorted := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		sorted.AddAttrs(sortMapAttr(a))
+		return true
+	})
+	return sorted
+}
+
+func sortMapAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, g := range group {
+			attrs[i] = sortMapAttr(g)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	case slog.KindAny:
+		v := reflect.ValueOf(a.Value.Any())
+		if v.Kind() != reflect.Map || v.IsNil() {
+			return a
+		}
+		attrs := make([]slog.Attr, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			attrs = append(attrs, sortMapAttr(slog.Any(fmt.Sprint(iter.Key().Interface()), iter.Value().Interface())))
+		}
+		sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+	return a
+}
@@ -0,0 +1,233 @@
+package slogf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// Option configures the global logger in Init().
+type Option func(*settings)
+
+//
+// settings are what the options set, starting from the defaults of defaultSettings().
+type settings struct {
+	level     slog.Level
+	format    string
+	output    io.Writer
+	addSource bool
+	// sourceFormat is one of the Source* constants, see WithSourceFormat().
+	sourceFormat string
+	timeFormat   string
+	timeZone     *time.Location
+	// replaceAttrs run after replaceAttr(), in order.
+	replaceAttrs []func(groups []string, a slog.Attr) slog.Attr
+	sampling     map[slog.Level]float64
+	redact       []string
+	// handler replaces the format handler, see WithHandler().
+	handler slog.Handler
+	static  []slog.Attr
+	// levelFormat and levelLabels are how the level is written, see WithLevelFormat().
+	levelFormat string
+	levelLabels map[slog.Level]string
+	// keyNames renames the built-in keys, see WithKeyNames().
+	keyNames map[string]string
+	// checksum appends a CRC to the lines, see WithChecksum().
+	checksum          bool
+	fatalFlushTimeout time.Duration
+	// fatalPanic panics rather than exiting after a FATAL record, see WithFatalPanic().
+	fatalPanic bool
+	// clock replaces the system clock, see WithClock().
+	clock func() time.Time
+	// callerSkip is the number of frames of wrappers, see WithCallerSkip().
+	callerSkip int
+	// foldMarker folds the values with newlines of the text format, see WithMultilineFold().
+	foldMarker string
+	// emittedAt adds the write time to the queued records, see WithEmittedAt().
+	emittedAt bool
+	// runtimeStats is the interval of the periodic runtime stats, see WithPeriodicRuntimeStats().
+	runtimeStats time.Duration
+	// inherited is set when the output was handed over by the parent process.
+	inherited bool
+	// errs are the invalid options, reported by InitE().
+	errs []error
+}
+
+var (
+	settingsMu sync.Mutex
+	// applied are the settings of the global logger.
+	applied = defaultSettings()
+)
+
+func defaultSettings() settings {
+	s := settings{level: slog.LevelInfo, format: "json", output: os.Stdout, addSource: true, fatalFlushTimeout: defaultFatalFlushTimeout}
+	if ciDefaults() {
+		s.level, s.format, s.sourceFormat = slog.LevelDebug, "text", SourceFull
+	}
+	return s
+}
+
+//
+// WithLevel() sets the minimum level, INFO by default.
+func WithLevel(level slog.Level) Option {
+	return func(s *settings) {
+		if level < slog.LevelDebug || level > LevelFatal {
+			s.errs = append(s.errs, fmt.Errorf("slogf: level %d out of range, want DEBUG (%d) to FATAL (%d)", level, slog.LevelDebug, LevelFatal))
+			return
+		}
+		s.level = level
+	}
+}
+
+//
+// WithDebug() sets the DEBUG level when on, the INFO level otherwise, as InitLogging() does.
+func WithDebug(on bool) Option {
+	return func(s *settings) {
+		s.level = slog.LevelInfo
+		if on {
+			s.level = slog.LevelDebug
+		}
+	}
+}
+
+//
+// WithFormat() sets the format, "text", "json" or one of RegisterFormat(), JSON by default or when
+// empty.
+func WithFormat(format string) Option {
+	return func(s *settings) {
+		f := strings.ToLower(format)
+		if f == "" {
+			f = "json"
+		}
+		if _, ok := lookupFormat(f); !ok && f != "text" && f != "json" {
+			s.errs = append(s.errs, fmt.Errorf("slogf: unknown format %q, want \"text\", \"json\" or one of RegisterFormat()", format))
+			return
+		}
+		s.format = f
+	}
+}
+
+//
+// WithOutput() sets where the records are written, os.Stdout by default.
+func WithOutput(w io.Writer) Option {
+	return func(s *settings) {
+		if w == nil {
+			s.errs = append(s.errs, errors.New("slogf: nil output"))
+			return
+		}
+		s.output = w
+	}
+}
+
+//
+// WithSource() turns the source of the records on or off, on by default. Off also saves looking up
+// the call site of every record, the sinks and the features keyed by call site then going without,
+// e.g. the error summaries group by message only.
+func WithSource(on bool) Option {
+	return func(s *settings) { s.addSource = on }
+}
+
+//
+// WithFullSource() reports the full path of the source file rather than its base name, as
+// WithSourceFormat(SourceFull).
+func WithFullSource(on bool) Option {
+	if !on {
+		return WithSourceFormat(SourceBase)
+	}
+	return WithSourceFormat(SourceFull)
+}
+
+//
+// WithStaticFields() adds the key value pairs to every record, in the output and the sinks, so the
+// call sites don't repeat the service metadata. Every call adds to the fields.
+// E.g. WithStaticFields("service", "payments", "version", version, "env", "prod")
+func WithStaticFields(args ...any) Option {
+	return func(s *settings) {
+		r := slog.Record{}
+		r.Add(args...)
+		static := s.static[:len(s.static):len(s.static)]
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "!BADKEY" {
+				s.errs = append(s.errs, fmt.Errorf("slogf: static field value %v without a key", a.Value))
+				return false
+			}
+			static = append(static, a)
+			return true
+		})
+		s.static = static
+	}
+}
+
+//
+// WithHostInfo() adds host and pid attrs to every record, so aggregators tell the replicas apart.
+// They come from SetHostnameResolver() and SetPIDProvider(), read once by Init().
+func WithHostInfo() Option {
+	return func(s *settings) {
+		WithStaticFields("host", hostname(), "pid", pid())(s)
+	}
+}
+
+//
+// WithHandler() makes h, e.g. the handler of a vendor, write the records instead of the text or
+// JSON one, keeping the functions of slogf, its FATAL exit and its pipeline features in front.
+// The format, output, level, source and ReplaceAttr options are then up to h, which gets FATAL
+// records at LevelFatal, shown as ERROR+4 by handlers that don't know it.
+func WithHandler(h slog.Handler) Option {
+	return func(s *settings) {
+		if h == nil {
+			s.errs = append(s.errs, errors.New("slogf: nil handler"))
+			return
+		}
+		s.handler = h
+	}
+}
+
+//
+// WithReplaceAttr() adds functions rewriting the attrs of the records as slog.HandlerOptions'
+// ReplaceAttr does, run in order after the built-in one that shortens the source and labels FATAL,
+// e.g. to rename keys or drop noisy attrs by returning an empty attr. Every call adds to the chain.
+// They apply to the output of Init(), the sinks having options of their own.
+// E.g. to rename msg to message
+//
+//	WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+//		if len(groups) == 0 && a.Key == slog.MessageKey {
+//			a.Key = "message"
+//		}
+//		return a
+//	})
+func WithReplaceAttr(fns ...func(groups []string, a slog.Attr) slog.Attr) Option {
+	return func(s *settings) {
+		for _, fn := range fns {
+			if fn == nil {
+				s.errs = append(s.errs, errors.New("slogf: nil ReplaceAttr"))
+				return
+			}
+		}
+		s.replaceAttrs = append(s.replaceAttrs[:len(s.replaceAttrs):len(s.replaceAttrs)], fns...)
+	}
+}
+
+//
+// SetFormat() switches the format of the global logger at runtime, keeping its other settings.
+// Records in flight go out in either format, never lost or mixed within a record.
+func SetFormat(format string) error {
+	return reconfigure(WithFormat(format))
+}
+
+//
+// SetOutput() switches the output of the global logger at runtime, keeping its other settings.
+func SetOutput(w io.Writer) error {
+	return reconfigure(WithOutput(w))
+}
+
+//
+// SetAddSource() turns the source of the records on or off at runtime, keeping the other settings.
+func SetAddSource(on bool) {
+	_ = reconfigure(WithSource(on))
+}
@@ -0,0 +1,19 @@
+package slogf
+
+import "log/slog"
+
+//
+// InitDev() is Init() with the defaults of local development: the console format, colored on
+// terminals with short timestamps, at DEBUG level. opts come after them, to change any.
+// E.g. InitDev(WithSourceFormat(SourceRelative))
+func InitDev(opts ...Option) {
+	Init(append([]Option{WithFormat("console"), WithLevel(slog.LevelDebug)}, opts...)...)
+}
+
+//
+// InitProd() is Init() with the defaults of production: JSON at INFO level, with full RFC 3339
+// timestamps to the nanosecond. opts come after them, to change any.
+// E.g. InitProd(WithStaticFields("service", "payments"), WithHostInfo())
+func InitProd(opts ...Option) {
+	Init(append([]Option{WithFormat("json"), WithLevel(slog.LevelInfo), WithTimeFormat(TimeRFC3339Nano)}, opts...)...)
+}
@@ -0,0 +1,134 @@
+package slogf
+
+import (
+	"log/slog"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Pressure ratios at which the watchdog drops DEBUG, then INFO records.
+const (
+	pressureDropDebug = 0.75
+	pressureDropInfo  = 0.9
+)
+
+//
+// Interval of StartPressureWatchdog() when the one given isn't positive.
+const defaultPressureInterval = time.Second
+
+var (
+	// pressureLevel is the minimum level let through, below DEBUG when not under pressure.
+	pressureLevel atomic.Int64
+
+	gaugesMu sync.Mutex
+	// Extra pressure sources, e.g. buffer fill ratios, each returning 0 (idle) to 1 (full).
+	gauges []*func() float64
+)
+
+func init() {
+	pressureLevel.Store(int64(slog.LevelDebug) - 1)
+}
+
+//
+// StartPressureWatchdog() checks the heap every interval against heapLimit (in bytes) and raises the
+// effective level under pressure: DEBUG records are dropped from 75% of the limit, INFO records from
+// 90%. A WARN diagnostic is logged when it engages, changes and recovers. A heapLimit of 0 is the
+// memory limit of the runtime, GOMEMLIMIT, the heap isn't checked when there is none, only the
+// buffers. The interval is a second when it isn't positive. Call the returned function to stop it,
+// which also lifts any raised level.
+func StartPressureWatchdog(heapLimit uint64, interval time.Duration) (stop func()) {
+	if heapLimit == 0 {
+		if limit := debug.SetMemoryLimit(-1); limit < math.MaxInt64 {
+			heapLimit = uint64(limit)
+		}
+	}
+	if interval <= 0 {
+		interval = defaultPressureInterval
+	}
+	t := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				checkPressure(heapLimit)
+			case <-done:
+				setPressureLevel(slog.LevelDebug-1, 0)
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+//
+// addPressureGauge() makes the watchdog take g into account next to the heap, until remove is called.
+func addPressureGauge(g func() float64) (remove func()) {
+	gaugesMu.Lock()
+	defer gaugesMu.Unlock()
+	p := &g
+	gauges = append(gauges, p)
+	return func() {
+		gaugesMu.Lock()
+		defer gaugesMu.Unlock()
+		for i, x := range gauges {
+			if x == p {
+				gauges = append(gauges[:i:i], gauges[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+//
+// checkPressure() sets the level from the pressure of the heap against heapLimit, not checked when
+// 0, and of the gauges.
+func checkPressure(heapLimit uint64) {
+	pressure := 0.0
+	if heapLimit > 0 {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		pressure = float64(m.HeapAlloc) / float64(heapLimit)
+	}
+	gaugesMu.Lock()
+	for _, g := range gauges {
+		if p := (*g)(); p > pressure {
+			pressure = p
+		}
+	}
+	gaugesMu.Unlock()
+
+	level := slog.LevelDebug - 1
+	switch {
+	case pressure >= pressureDropInfo:
+		level = slog.LevelWarn
+	case pressure >= pressureDropDebug:
+		level = slog.LevelInfo
+	}
+	setPressureLevel(level, pressure)
+}
+
+func setPressureLevel(level slog.Level, pressure float64) {
+	old := slog.Level(pressureLevel.Swap(int64(level)))
+	if old == level {
+		return
+	}
+	if level < slog.LevelDebug {
+		notice(slog.LevelWarn, "slogf: memory pressure recovered", slog.Float64("pressure", pressure))
+		return
+	}
+	notice(slog.LevelWarn, "slogf: memory pressure, raising level", slog.String("min_level", level.String()), slog.Float64("pressure", pressure))
+}
+
+//
+// underPressure() tells whether records at level are currently dropped by the watchdog.
+func underPressure(level slog.Level) bool {
+	return level < slog.Level(pressureLevel.Load())
+}
@@ -0,0 +1,43 @@
+package slogf
+
+import (
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"testing"
+)
+
+func TestPressureWatchdog(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	if err := Reinit(WithOutput(io.Discard)); err != nil {
+		t.Fatal(err)
+	}
+	defer setPressureLevel(slog.LevelDebug-1, 0)
+
+	// Without a limit the heap isn't checked, rather than being infinitely over it.
+	checkPressure(0)
+	if underPressure(slog.LevelDebug) {
+		t.Error("no heap limit dropped DEBUG records")
+	}
+	checkPressure(1)
+	if !underPressure(slog.LevelInfo) || underPressure(slog.LevelWarn) {
+		t.Error("a heap over its limit didn't drop INFO records only")
+	}
+	remove := addPressureGauge(func() float64 { return 0.8 })
+	checkPressure(0)
+	if !underPressure(slog.LevelDebug) || underPressure(slog.LevelInfo) {
+		t.Error("a gauge at 80% didn't drop DEBUG records only")
+	}
+	remove()
+	setPressureLevel(slog.LevelDebug-1, 0)
+
+	// A zero interval and limit fall back to the defaults rather than panicking.
+	old := debug.SetMemoryLimit(-1)
+	defer debug.SetMemoryLimit(old)
+	for _, limit := range []int64{1 << 62, 1} {
+		debug.SetMemoryLimit(limit)
+		stop := StartPressureWatchdog(0, 0)
+		stop()
+		stop()
+	}
+}
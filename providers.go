@@ -0,0 +1,116 @@
+package slogf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync/atomic"
+)
+
+//
+// IDGenerator makes the request and correlation IDs slogf generates.
+type IDGenerator interface {
+	NewID() string
+}
+
+//
+// HostnameResolver gives the host name of the enrichment fields.
+type HostnameResolver interface {
+	Hostname() (string, error)
+}
+
+//
+// PIDProvider gives the process ID of the enrichment fields.
+type PIDProvider interface {
+	PID() int
+}
+
+type randomIDs struct{}
+
+//
+// NewID() returns 16 random bytes in hex, the size of a W3C trace ID.
+func (randomIDs) NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+type osHost struct{}
+
+func (osHost) Hostname() (string, error) { return os.Hostname() }
+
+type osPID struct{}
+
+func (osPID) PID() int { return os.Getpid() }
+
+type providers struct {
+	ids  IDGenerator
+	host HostnameResolver
+	pid  PIDProvider
+}
+
+var (
+	provided atomic.Pointer[providers]
+)
+
+func init() {
+	provided.Store(&providers{ids: randomIDs{}, host: osHost{}, pid: osPID{}})
+}
+
+//
+// SetIDGenerator() replaces the generator of request and correlation IDs, e.g. with a
+// deterministic one in tests. nil restores the default of random 128-bit hex IDs.
+func SetIDGenerator(g IDGenerator) {
+	if g == nil {
+		g = randomIDs{}
+	}
+	setProviders(func(p *providers) { p.ids = g })
+}
+
+//
+// SetHostnameResolver() replaces the source of the host name, e.g. for environments like Lambda or
+// Cloud Run where os.Hostname() is meaningless. nil restores os.Hostname().
+func SetHostnameResolver(r HostnameResolver) {
+	if r == nil {
+		r = osHost{}
+	}
+	setProviders(func(p *providers) { p.host = r })
+}
+
+//
+// SetPIDProvider() replaces the source of the process ID. nil restores os.Getpid().
+func SetPIDProvider(p PIDProvider) {
+	if p == nil {
+		p = osPID{}
+	}
+	setProviders(func(ps *providers) { ps.pid = p })
+}
+
+func setProviders(set func(p *providers)) {
+	for {
+		old := provided.Load()
+		p := *old
+		set(&p)
+		if provided.CompareAndSwap(old, &p) {
+			return
+		}
+	}
+}
+
+//
+// newID(), hostname() and pid() are the enrichment sources, as configured.
+func newID() string {
+	return provided.Load().ids.NewID()
+}
+
+func hostname() string {
+	h, err := provided.Load().host.Hostname()
+	if err != nil {
+		return ""
+	}
+	return h
+}
+
+func pid() int {
+	return provided.Load().pid.PID()
+}
@@ -0,0 +1,237 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+//
+// PubSubOptions configure a PubSubHandler.
+type PubSubOptions struct {
+	// Project and Topic the records are published to.
+	Project string
+	Topic   string
+	// URL of the API, https://pubsub.googleapis.com when empty, e.g. a regional endpoint for ordering
+	// keys or http://localhost:8085 for the emulator.
+	URL string
+	// Token returns the OAuth2 access token of a request, e.g. from a TokenSource of
+	// golang.org/x/oauth2/google. Requests are sent without one when nil, as the emulator wants.
+	Token func(ctx context.Context) (string, error)
+	// OrderingKey is the key of the attr whose value is the ordering key, e.g. "order_id", so the
+	// records of an order are delivered in order. The topic subscription must enable ordering.
+	OrderingKey string
+	// MaxOutstandingMessages and MaxOutstandingBytes cap the records queued or being published,
+	// 10000 and 100MB when 0. Records over them are dropped rather than piling up in memory while
+	// Pub/Sub is slow, and counted as dropped in the stats of the shutdown record. ERROR and FATAL
+	// records are never dropped.
+	MaxOutstandingMessages int
+	MaxOutstandingBytes    int
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Records per publish request, 100 when 0, at most 1000. Requests also happen every
+	// FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	Client        *http.Client
+	// OnError receives publish failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+type pubsubMessage struct {
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+//
+// Limits of a publish request.
+const (
+	pubsubMaxMessages = 1000
+	pubsubMaxBytes    = 9 << 20
+)
+
+//
+// pubsubFlow counts the records of a PubSubHandler queued or being published.
+type pubsubFlow struct {
+	messages atomic.Int64
+	bytes    atomic.Int64
+}
+
+//
+// PubSubHandler publishes records as JSON messages to a Google Cloud Pub/Sub topic, e.g. for the
+// Dataflow template streaming them into BigQuery. Messages carry the level as an attribute for
+// subscription filters.
+type PubSubHandler struct {
+	opts  *PubSubOptions
+	url   string
+	enc   *recordEncoder
+	batch *batcher[pubsubMessage]
+	flow  *pubsubFlow
+	// key is the ordering key from WithAttrs(), "" when none.
+	key string
+	// grouped tells attrs are under a group, no longer the top-level ordering key.
+	grouped bool
+}
+
+//
+// NewPubSubHandler() starts a PubSubHandler. Add it with AddSink() and Close() it on shutdown.
+func NewPubSubHandler(opts PubSubOptions) (*PubSubHandler, error) {
+	if opts.Project == "" || opts.Topic == "" {
+		return nil, errors.New("pubsub: Project and Topic are required")
+	}
+	if opts.URL == "" {
+		opts.URL = "https://pubsub.googleapis.com"
+	}
+	if opts.BatchSize > pubsubMaxMessages {
+		opts.BatchSize = pubsubMaxMessages
+	}
+	if opts.MaxOutstandingMessages <= 0 {
+		opts.MaxOutstandingMessages = 10000
+	}
+	if opts.MaxOutstandingBytes <= 0 {
+		opts.MaxOutstandingBytes = 100 << 20
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	h := &PubSubHandler{
+		opts: &opts,
+		url:  opts.URL + "/v1/projects/" + url.PathEscape(opts.Project) + "/topics/" + url.PathEscape(opts.Topic) + ":publish",
+		enc:  newJSONEncoder(&slog.HandlerOptions{AddSource: true, Level: opts.Level, ReplaceAttr: replaceAttr}),
+		flow: &pubsubFlow{},
+	}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.publish, opts.OnError)
+	return h, nil
+}
+
+func (h *PubSubHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enc.h.Enabled(ctx, level)
+}
+
+func (h *PubSubHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, err := h.enc.encode(ctx, r)
+	if err != nil {
+		return err
+	}
+	messages, size := h.flow.messages.Add(1), h.flow.bytes.Add(int64(len(line)))
+	if messages > int64(h.opts.MaxOutstandingMessages) || size > int64(h.opts.MaxOutstandingBytes) {
+		if r.Level < slog.LevelError {
+			h.flow.messages.Add(-1)
+			h.flow.bytes.Add(-int64(len(line)))
+			droppedRecords.Add(1)
+			return nil
+		}
+	}
+	key := h.key
+	if h.opts.OrderingKey != "" && !h.grouped {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == h.opts.OrderingKey {
+				key = a.Value.Resolve().String()
+			}
+			return true
+		})
+	}
+	h.batch.add(pubsubMessage{Data: line, Attributes: map[string]string{slog.LevelKey: levelLabel(r.Level)}, OrderingKey: key})
+	return nil
+}
+
+func (h *PubSubHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.enc = h.enc.WithAttrs(attrs)
+	if h.opts.OrderingKey != "" && !h.grouped {
+		for _, a := range attrs {
+			if a.Key == h.opts.OrderingKey {
+				c.key = a.Value.Resolve().String()
+			}
+		}
+	}
+	return &c
+}
+
+func (h *PubSubHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.enc = h.enc.WithGroup(name)
+	c.grouped = true
+	return &c
+}
+
+//
+// Flush() publishes the queued records.
+func (h *PubSubHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after publishing the queued records.
+func (h *PubSubHandler) Close() error {
+	return h.batch.Close()
+}
+
+//
+// publish() sends messages in requests within the size limit of one.
+func (h *PubSubHandler) publish(messages []pubsubMessage) error {
+	stampBatch(messages, func(m *pubsubMessage, at time.Time) {
+		size := len(m.Data)
+		m.Data = stampLine(m.Data, at)
+		h.flow.bytes.Add(int64(len(m.Data) - size))
+	})
+	var errs []error
+	for len(messages) > 0 {
+		n, size := 0, 0
+		for n < len(messages) {
+			size += len(messages[n].Data)
+			if n > 0 && size > pubsubMaxBytes {
+				break
+			}
+			n++
+		}
+		if err := h.send(messages[:n]); err != nil {
+			errs = append(errs, err)
+		}
+		for _, m := range messages[:n] {
+			h.flow.messages.Add(-1)
+			h.flow.bytes.Add(-int64(len(m.Data)))
+		}
+		messages = messages[n:]
+	}
+	return errors.Join(errs...)
+}
+
+func (h *PubSubHandler) send(messages []pubsubMessage) error {
+	body, err := json.Marshal(map[string]any{"messages": messages})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.opts.Token != nil {
+		token, err := h.opts.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("pubsub publish: token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pubsub publish: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pubsub publish: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
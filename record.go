@@ -0,0 +1,118 @@
+package slogf
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+//
+// CloneRecord() returns a copy of r safe to keep and re-emit later, e.g. from a buffer or a relay.
+// Unlike r.Clone(), groups are copied too and values are resolved, so the copy is a snapshot that
+// a slog.LogValuer changing afterwards, or a handler adding attrs to either record, can't affect.
+// Secret() values stay unresolved, so they still render per sink.
+func CloneRecord(r slog.Record) slog.Record {
+	c := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		c.AddAttrs(cloneAttr(a))
+		return true
+	})
+	return c
+}
+
+//
+// Reemit() passes a copy of a captured record to h, e.g. another logger's handler or a sink,
+// when h is enabled for its level.
+// E.g. Reemit(ctx, Logger.Handler(), r)
+func Reemit(ctx context.Context, h slog.Handler, r slog.Record) error {
+	if !h.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.Handle(ctx, CloneRecord(r))
+}
+
+func cloneAttr(a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindLogValuer {
+		if _, ok := a.Value.Any().(secret); ok {
+			return a
+		}
+	}
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, g := range group {
+			attrs[i] = cloneAttr(g)
+		}
+		a.Value = slog.GroupValue(attrs...)
+	}
+	return a
+}
+
+//
+// RecordToMap() converts r to the object the JSON handler would write, with the time, level, msg
+// and, when r has a call site, source keys, and the attrs nested by group, so hooks and sinks don't
+// walk the attrs themselves. Values are made of maps, slices and scalars.
+func RecordToMap(r slog.Record) map[string]any {
+	m := make(map[string]any, r.NumAttrs()+4)
+	if !r.Time.IsZero() {
+		m[slog.TimeKey] = r.Time.Format(time.RFC3339Nano)
+	}
+	m[slog.LevelKey] = levelValue(r.Level).Any()
+	m[slog.MessageKey] = r.Message
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		m[slog.SourceKey] = map[string]any{"function": f.Function, "file": f.File, "line": f.Line}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttrToMap(m, a)
+		return true
+	})
+	return m
+}
+
+//
+// RecordToJSON() is RecordToMap() encoded as JSON, with the keys sorted.
+func RecordToJSON(r slog.Record) ([]byte, error) {
+	return json.Marshal(RecordToMap(r))
+}
+
+//
+// addAttrToMap() adds a to m the way the JSON handler writes it, skipping empty attrs and groups
+// and inlining groups without a key.
+func addAttrToMap(m map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		m[a.Key] = valueToAny(a.Value)
+		return
+	}
+	group := a.Value.Group()
+	if len(group) == 0 {
+		return
+	}
+	if a.Key == "" {
+		for _, g := range group {
+			addAttrToMap(m, g)
+		}
+		return
+	}
+	sub := make(map[string]any, len(group))
+	for _, g := range group {
+		addAttrToMap(sub, g)
+	}
+	m[a.Key] = sub
+}
+
+//
+// levelLabel() returns the label of level in the output, FATAL for LevelFatal.
+func levelLabel(level slog.Level) string {
+	if level == LevelFatal {
+		return "FATAL"
+	}
+	return level.String()
+}
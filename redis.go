@@ -0,0 +1,271 @@
+package slogf
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+)
+
+//
+// RedisOptions configure a RedisHandler.
+type RedisOptions struct {
+	// Addr of the Redis server, localhost:6379 when empty.
+	Addr string
+	// Username and Password for AUTH, none when Password is empty. Username is for Redis 6 ACLs.
+	Username string
+	Password string
+	// DB is selected after connecting, 0 by default.
+	DB int
+	// TLS connects over TLS when set.
+	TLS *tls.Config
+	// Stream the records are added to.
+	Stream string
+	// MaxLen trims the stream to about that many entries at every XADD, 0 for no trimming.
+	MaxLen int
+	// Minimum level, INFO when nil.
+	Level slog.Leveler
+	// Records per round trip, 100 when 0. Round trips also happen every FlushInterval, 1s when 0.
+	BatchSize     int
+	FlushInterval time.Duration
+	// Timeout of connecting and of a round trip, 5s when 0.
+	Timeout time.Duration
+	// OnError receives add failures, they go to stderr when nil.
+	OnError func(err error)
+}
+
+type redisEntry struct {
+	level string
+	data  []byte
+}
+
+//
+// RedisHandler adds records to a Redis stream with XADD, the entries having a level field and a
+// data field holding the JSON line, as a lightweight central buffer that consumer groups read.
+type RedisHandler struct {
+	opts  *RedisOptions
+	enc   *recordEncoder
+	batch *batcher[redisEntry]
+	rc    *redisConn
+}
+
+//
+// redisConn is the connection shared by a RedisHandler and the ones derived from it, used by
+// send() under the send lock of the batcher.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+//
+// NewRedisHandler() starts a RedisHandler, connecting on the first batch. Add it with AddSink()
+// and Close() it on shutdown.
+func NewRedisHandler(opts RedisOptions) (*RedisHandler, error) {
+	if opts.Stream == "" {
+		return nil, errors.New("redis: Stream is required")
+	}
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	h := &RedisHandler{opts: &opts, rc: &redisConn{}, enc: newJSONEncoder(&slog.HandlerOptions{AddSource: true, Level: opts.Level, ReplaceAttr: replaceAttr})}
+	h.batch = newBatcher(opts.BatchSize, opts.FlushInterval, h.send, opts.OnError)
+	return h, nil
+}
+
+func (h *RedisHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enc.h.Enabled(ctx, level)
+}
+
+func (h *RedisHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, err := h.enc.encode(ctx, r)
+	if err != nil {
+		return err
+	}
+	h.batch.add(redisEntry{level: levelLabel(r.Level), data: line})
+	return nil
+}
+
+func (h *RedisHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RedisHandler{opts: h.opts, enc: h.enc.WithAttrs(attrs), batch: h.batch, rc: h.rc}
+}
+
+func (h *RedisHandler) WithGroup(name string) slog.Handler {
+	return &RedisHandler{opts: h.opts, enc: h.enc.WithGroup(name), batch: h.batch, rc: h.rc}
+}
+
+//
+// Flush() adds the queued records.
+func (h *RedisHandler) Flush() error {
+	return h.batch.Flush()
+}
+
+//
+// Close() stops the handler after adding the queued records.
+func (h *RedisHandler) Close() error {
+	err := h.batch.Close()
+	if h.rc.conn != nil {
+		h.rc.conn.Close()
+		h.rc.conn = nil
+	}
+	return err
+}
+
+//
+// send() pipelines the XADD of every entry in one round trip, reconnecting after a failure.
+func (h *RedisHandler) send(entries []redisEntry) error {
+	stampBatch(entries, func(e *redisEntry, at time.Time) { e.data = stampLine(e.data, at) })
+	if h.rc.conn == nil {
+		if err := h.connect(); err != nil {
+			return fmt.Errorf("redis xadd: %w", err)
+		}
+	}
+	var cmds []byte
+	for _, e := range entries {
+		args := []string{"XADD", h.opts.Stream}
+		if h.opts.MaxLen > 0 {
+			args = append(args, "MAXLEN", "~", strconv.Itoa(h.opts.MaxLen))
+		}
+		args = append(args, "*", "level", e.level, "data", string(e.data))
+		cmds = appendRESP(cmds, args...)
+	}
+	var errs []error
+	if err := h.roundTrip(cmds, len(entries), func(err error) { errs = append(errs, err) }); err != nil {
+		h.rc.conn.Close()
+		h.rc.conn = nil
+		return fmt.Errorf("redis xadd: %w", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("redis xadd: %d of %d entries failed: %w", len(errs), len(entries), errs[0])
+	}
+	return nil
+}
+
+func (h *RedisHandler) connect() error {
+	d := &net.Dialer{Timeout: h.opts.Timeout}
+	var conn net.Conn
+	var err error
+	if h.opts.TLS != nil {
+		conn, err = tls.DialWithDialer(d, "tcp", h.opts.Addr, h.opts.TLS)
+	} else {
+		conn, err = d.Dial("tcp", h.opts.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	h.rc.conn, h.rc.rd = conn, bufio.NewReader(conn)
+
+	var cmds []byte
+	n := 0
+	if h.opts.Password != "" {
+		if h.opts.Username != "" {
+			cmds = appendRESP(cmds, "AUTH", h.opts.Username, h.opts.Password)
+		} else {
+			cmds = appendRESP(cmds, "AUTH", h.opts.Password)
+		}
+		n++
+	}
+	if h.opts.DB != 0 {
+		cmds = appendRESP(cmds, "SELECT", strconv.Itoa(h.opts.DB))
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	var failed error
+	err = h.roundTrip(cmds, n, func(err error) {
+		if failed == nil {
+			failed = err
+		}
+	})
+	if err == nil {
+		err = failed
+	}
+	if err != nil {
+		conn.Close()
+		h.rc.conn = nil
+	}
+	return err
+}
+
+//
+// roundTrip() writes cmds and reads n replies, passing the error replies to onReply. It returns
+// the errors of the connection, after which it can't be used any more.
+func (h *RedisHandler) roundTrip(cmds []byte, n int, onReply func(err error)) error {
+	h.rc.conn.SetDeadline(time.Now().Add(h.opts.Timeout))
+	if _, err := h.rc.conn.Write(cmds); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := readRESP(h.rc.rd, onReply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// appendRESP() appends a command in the RESP protocol of Redis to b.
+func appendRESP(b []byte, args ...string) []byte {
+	b = append(b, '*')
+	b = strconv.AppendInt(b, int64(len(args)), 10)
+	b = append(b, "\r\n"...)
+	for _, a := range args {
+		b = append(b, '$')
+		b = strconv.AppendInt(b, int64(len(a)), 10)
+		b = append(b, "\r\n"...)
+		b = append(b, a...)
+		b = append(b, "\r\n"...)
+	}
+	return b
+}
+
+//
+// readRESP() reads a reply, skipping its value and passing an error reply to onReply.
+func readRESP(rd *bufio.Reader, onReply func(err error)) error {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return fmt.Errorf("malformed reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+', ':':
+		return nil
+	case '-':
+		onReply(errors.New(value))
+		return nil
+	case '$':
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("malformed reply %q", line)
+		}
+		if size < 0 {
+			return nil
+		}
+		_, err = io.CopyN(io.Discard, rd, int64(size)+2)
+		return err
+	case '*':
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("malformed reply %q", line)
+		}
+		for i := 0; i < count; i++ {
+			if err := readRESP(rd, onReply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,168 @@
+package slogf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readCommand reads a command written by appendRESP(), an array of bulk strings.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") || !strings.HasSuffix(line, "\r\n") {
+		return nil, errors.New("not an array: " + line)
+	}
+	n, err := strconv.Atoi(line[1 : len(line)-2])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, errors.New("not a bulk string: " + line)
+		}
+		size, err := strconv.Atoi(line[1 : len(line)-2])
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, err
+		}
+		if string(b[size:]) != "\r\n" {
+			return nil, errors.New("bulk string not terminated")
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func TestAppendRESP(t *testing.T) {
+	got := appendRESP(nil, "SET", "k", "")
+	if want := "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$0\r\n\r\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	commands := [][]string{
+		{"XADD", "logs", "*", "data", `{"msg":"a\r\nb"}`},
+		{"AUTH", "user", "pa$$\r\n*2"},
+		{"PING"},
+		{"SET", "é", strings.Repeat("x", 70000)},
+	}
+	var b []byte
+	for _, c := range commands {
+		b = appendRESP(b, c...)
+	}
+	rd := bufio.NewReader(strings.NewReader(string(b)))
+	for _, want := range commands {
+		got, err := readCommand(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if _, err := rd.ReadByte(); err != io.EOF {
+		t.Errorf("trailing bytes after the commands")
+	}
+}
+
+func TestReadRESP(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		replies []string // error replies
+		err     string
+	}{
+		{"simple string", "+OK\r\n", nil, ""},
+		{"integer", ":42\r\n", nil, ""},
+		{"error", "-ERR wrong type\r\n", []string{"ERR wrong type"}, ""},
+		{"bulk string", "$5\r\nab\r\nc\r\n", nil, ""},
+		{"null bulk string", "$-1\r\n", nil, ""},
+		{"stream ID", "$15\r\n1700000000000-0\r\n", nil, ""},
+		{"nested array", "*2\r\n*1\r\n-ERR a\r\n$1\r\nx\r\n", []string{"ERR a"}, ""},
+		{"empty array", "*0\r\n", nil, ""},
+		{"no CRLF", "+OK\n", nil, "malformed reply"},
+		{"short", "+\r\n", nil, ""},
+		{"too short", "\r\n", nil, "malformed reply"},
+		{"bad size", "$x\r\n", nil, "malformed reply"},
+		{"bad count", "*x\r\n", nil, "malformed reply"},
+		{"unknown kind", "%2\r\n", nil, "unexpected reply"},
+		{"truncated bulk", "$10\r\nab", nil, "EOF"},
+		{"truncated array", "*2\r\n+OK\r\n", nil, "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replies []string
+			err := readRESP(bufio.NewReader(strings.NewReader(tt.in)), func(err error) { replies = append(replies, err.Error()) })
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+			if !reflect.DeepEqual(replies, tt.replies) {
+				t.Errorf("got error replies %q, want %q", replies, tt.replies)
+			}
+		})
+	}
+}
+
+func TestRedisHandler(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	got := make(chan []string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		for {
+			cmd, err := readCommand(rd)
+			if err != nil {
+				return
+			}
+			got <- cmd
+			conn.Write([]byte("$15\r\n1700000000000-0\r\n"))
+		}
+	}()
+
+	h, err := NewRedisHandler(RedisOptions{Addr: ln.Addr().String(), Stream: "logs", MaxLen: 1000, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	logger := slog.New(h)
+	logger.Info("first", "k", 1)
+	logger.Warn("second")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct{ level, msg string }{{"INFO", `"msg":"first","k":1`}, {"WARN", `"msg":"second"`}} {
+		cmd := <-got
+		if len(cmd) != 10 || !reflect.DeepEqual(cmd[:8], []string{"XADD", "logs", "MAXLEN", "~", "1000", "*", "level", want.level}) || cmd[8] != "data" || !strings.Contains(cmd[9], want.msg) {
+			t.Errorf("got command %q", cmd)
+		}
+	}
+
+	if _, err := NewRedisHandler(RedisOptions{}); err == nil {
+		t.Error("NewRedisHandler() without a Stream succeeded")
+	}
+}
@@ -0,0 +1,180 @@
+package slogf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//
+// FormatFactory returns the handler writing records to w in a format, with the options of Init().
+type FormatFactory func(w io.Writer, opts *slog.HandlerOptions) slog.Handler
+
+//
+// SinkFactory returns a sink from the params of its config, e.g. {"url": "http://loki:3100"}.
+type SinkFactory func(params map[string]string) (slog.Handler, error)
+
+var (
+	registryMu    sync.RWMutex
+	formats       = map[string]FormatFactory{}
+	sinkFactories = map[string]SinkFactory{}
+)
+
+//
+// RegisterFormat() makes a format available by name to WithFormat() and the config, so a module
+// can contribute one without slogf importing it, typically from its init(). Names are case
+// insensitive. It panics if the name is text or json or registered twice, as sql.Register() does.
+// E.g. RegisterFormat("logfmt", func(w io.Writer, opts *slog.HandlerOptions) slog.Handler { ... })
+func RegisterFormat(name string, f FormatFactory) {
+	name = strings.ToLower(name)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if f == nil {
+		panic("slogf: RegisterFormat factory is nil")
+	}
+	if _, dup := formats[name]; dup || name == "text" || name == "json" {
+		panic("slogf: RegisterFormat called twice for " + name)
+	}
+	formats[name] = f
+}
+
+//
+// RegisterSink() makes a sink available by name to the sinks of the config, Config.Sinks or
+// SLOGF_SINKS, so a module can contribute one without slogf importing it. Names are case
+// insensitive. It panics if the name is registered twice.
+// E.g. RegisterSink("kafka", func(params map[string]string) (slog.Handler, error) { ... })
+func RegisterSink(name string, f SinkFactory) {
+	name = strings.ToLower(name)
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if f == nil {
+		panic("slogf: RegisterSink factory is nil")
+	}
+	if _, dup := sinkFactories[name]; dup {
+		panic("slogf: RegisterSink called twice for " + name)
+	}
+	sinkFactories[name] = f
+}
+
+//
+// registeredFormats() and registeredSinks() return the sorted names of RegisterFormat() and
+// RegisterSink(), for the error messages.
+func registeredFormats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return sortedKeys(formats)
+}
+
+func registeredSinks() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return sortedKeys(sinkFactories)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lookupFormat(name string) (FormatFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+//
+// SinkConfig is a sink of Config, by the name it was registered with.
+type SinkConfig struct {
+	Type   string     `json:"type" yaml:"type"`
+	Params SinkParams `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+//
+// SinkParams are the params of a sink. Numbers and booleans of a config file are taken as text.
+type SinkParams map[string]string
+
+func (p *SinkParams) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = make(SinkParams, len(raw))
+	for k, v := range raw {
+		var s string
+		if json.Unmarshal(v, &s) != nil {
+			s = string(v)
+		}
+		(*p)[k] = s
+	}
+	return nil
+}
+
+//
+// parseSinks() parses the SLOGF_SINKS variable, sinks separated by semicolons with their params as
+// a query, e.g. "kafka?brokers=k1:9092,k2:9092&topic=logs;stderr".
+func parseSinks(s string) ([]SinkConfig, error) {
+	var sinks []SinkConfig
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, query, _ := strings.Cut(entry, "?")
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("slogf: SLOGF_SINKS: %s: %w", name, err)
+		}
+		params := make(SinkParams, len(values))
+		for k, v := range values {
+			params[k] = v[len(v)-1]
+		}
+		sinks = append(sinks, SinkConfig{Type: name, Params: params})
+	}
+	return sinks, nil
+}
+
+//
+// newSinks() returns the sinks of cfgs, closing the ones it made if one fails.
+func newSinks(cfgs []SinkConfig) ([]slog.Handler, error) {
+	var hs []slog.Handler
+	fail := func(err error) ([]slog.Handler, error) {
+		closeHandlers(hs)
+		return nil, err
+	}
+	for _, c := range cfgs {
+		registryMu.RLock()
+		f, ok := sinkFactories[strings.ToLower(c.Type)]
+		registryMu.RUnlock()
+		if !ok {
+			return fail(fmt.Errorf("slogf: unknown sink %q, not registered with RegisterSink()", c.Type))
+		}
+		h, err := f(c.Params)
+		if err != nil {
+			return fail(fmt.Errorf("slogf: sink %s: %w", c.Type, err))
+		}
+		if h == nil {
+			return fail(errors.New("slogf: sink " + c.Type + ": nil handler"))
+		}
+		hs = append(hs, h)
+	}
+	return hs, nil
+}
+
+func closeHandlers(hs []slog.Handler) {
+	for _, h := range hs {
+		if c, ok := h.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	reservedPrefix atomic.Pointer[string]
+	// reservedSeen keeps the call sites and keys already diagnosed.
+	reservedSeen sync.Map
+)
+
+//
+// RenameReservedKeys() renames the attrs of the callers using a core key, time, level, msg or
+// source, or one of their names of WithKeyNames(), with the prefix, e.g. "fields." gives
+// fields.time, so the output never has duplicated core keys. An empty prefix turns the renaming off.
+// At DEBUG level, a WARN diagnostic reports each call site using a reserved key, renamed or not.
+func RenameReservedKeys(prefix string) {
+	if prefix == "" {
+		reservedPrefix.Store(nil)
+		return
+	}
+	reservedPrefix.Store(&prefix)
+}
+
+//
+// isReservedKey() tells whether key is a core key, or the name WithKeyNames() gives one.
+func isReservedKey(key string) bool {
+	switch key {
+	case slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey:
+		return true
+	}
+	if names := renamedKeys.Load(); names != nil {
+		return (*names)[key]
+	}
+	return false
+}
+
+//
+// guardReservedKeys() diagnoses and renames the top-level attrs of r using a reserved key.
+func (h *handler) guardReservedKeys(ctx context.Context, r slog.Record) slog.Record {
+	prefix := reservedPrefix.Load()
+	if prefix == nil && !logDebug.Load() {
+		return r
+	}
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = isReservedKey(a.Key)
+		return !found
+	})
+	if !found {
+		return r
+	}
+	guarded := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if isReservedKey(a.Key) {
+			if logDebug.Load() && r.PC != 0 {
+				if _, seen := reservedSeen.LoadOrStore(strconv.FormatUint(uint64(r.PC), 16)+a.Key, true); !seen {
+					renamed := a.Key
+					if prefix != nil {
+						renamed = *prefix + a.Key
+					}
+					h.diagnose(ctx, r, slog.LevelWarn, "slogf: attr uses a reserved key", "key", a.Key, "renamed", renamed)
+				}
+			}
+			if prefix != nil {
+				a.Key = *prefix + a.Key
+			}
+		}
+		guarded.AddAttrs(a)
+		return true
+	})
+	return guarded
+}
+
+//
+// renameReservedAttrs() renames the attrs of Logger.With() using a reserved key.
+func renameReservedAttrs(attrs []slog.Attr) []slog.Attr {
+	prefix := reservedPrefix.Load()
+	if prefix == nil {
+		return attrs
+	}
+	var renamed []slog.Attr
+	for i, a := range attrs {
+		if !isReservedKey(a.Key) {
+			continue
+		}
+		if renamed == nil {
+			renamed = append([]slog.Attr(nil), attrs...)
+		}
+		renamed[i].Key = *prefix + a.Key
+	}
+	if renamed == nil {
+		return attrs
+	}
+	return renamed
+}
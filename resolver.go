@@ -0,0 +1,93 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// LevelResolver lets an external system, e.g. feature flags, drive the level per record, for
+// instance "debug logs for tenant 42 for 1 hour". Resolutions are cached per key, see
+// SetLevelResolver().
+type LevelResolver interface {
+	// LevelKey() returns the key of the record context the level depends on, e.g. the tenant ID,
+	// "" when the configured level applies.
+	LevelKey(ctx context.Context) string
+	// ResolveLevel() returns the level of the key, ok false when the configured level applies.
+	ResolveLevel(key string) (level slog.Level, ok bool)
+}
+
+type forcedLevelKey struct{}
+
+type resolvedLevel struct {
+	level   slog.Level
+	ok      bool
+	expires time.Time
+}
+
+type levelResolution struct {
+	resolver LevelResolver
+	ttl      time.Duration
+	mu       sync.Mutex
+	cache    map[string]resolvedLevel
+}
+
+var (
+	levelResolver atomic.Pointer[levelResolution]
+)
+
+//
+// SetLevelResolver() consults r for the effective level of every record logged with a context,
+// caching each key's level for ttl. The resolved level replaces the configured one both ways,
+// it can turn DEBUG on as well as raise the level. A nil r removes the resolver.
+func SetLevelResolver(r LevelResolver, ttl time.Duration) {
+	if r == nil {
+		levelResolver.Store(nil)
+		return
+	}
+	levelResolver.Store(&levelResolution{resolver: r, ttl: ttl, cache: map[string]resolvedLevel{}})
+}
+
+//
+// resolveLevel() returns the level the resolver gives ctx, ok false when there is none.
+func resolveLevel(ctx context.Context) (slog.Level, bool) {
+	lr := levelResolver.Load()
+	if lr == nil || ctx == nil {
+		return 0, false
+	}
+	key := lr.resolver.LevelKey(ctx)
+	if key == "" {
+		return 0, false
+	}
+	t := time.Now()
+	lr.mu.Lock()
+	c, hit := lr.cache[key]
+	lr.mu.Unlock()
+	if !hit || t.After(c.expires) {
+		c.level, c.ok = lr.resolver.ResolveLevel(key)
+		c.expires = t.Add(lr.ttl)
+		lr.mu.Lock()
+		if len(lr.cache) >= maxResolvedKeys {
+			// Keys like user IDs are unbounded, start over rather than grow.
+			clear(lr.cache)
+		}
+		lr.cache[key] = c
+		lr.mu.Unlock()
+	}
+	return c.level, c.ok
+}
+
+//
+// Maximum number of keys cached by the level resolver.
+const maxResolvedKeys = 10000
+
+//
+// forcedLevel() tells whether the record of ctx is below the configured level but enabled by the
+// LevelResolver or a Target() rule.
+func forcedLevel(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcedLevelKey{}).(bool)
+	return forced
+}
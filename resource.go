@@ -0,0 +1,68 @@
+package slogf
+
+import (
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var (
+	// resource are the OTel resource attributes merged into every record.
+	resource []slog.Attr
+)
+
+//
+// SetResource() merges the key value pairs into every record as OTel resource attributes, keeping
+// logs and traces labeled the same. Apps with an OTel SDK can pass their resource:
+//
+//	for _, kv := range res.Attributes() {
+//	    args = append(args, string(kv.Key), kv.Value.Emit())
+//	}
+//	SetResource(args...)
+func SetResource(args ...any) {
+	r := slog.Record{}
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	resource = attrs
+	rebuild()
+}
+
+//
+// UseOTelResource() reads the resource configured for the OTel SDK through the standard
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME environment variables and merges it into every record.
+// E.g. OTEL_RESOURCE_ATTRIBUTES=service.name=payments,deployment.environment=prod
+func UseOTelResource() {
+	var args []any
+	service := ""
+	for _, kv := range strings.Split(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		if u, err := url.PathUnescape(strings.TrimSpace(v)); err == nil {
+			v = u
+		}
+		if k == "service.name" {
+			service = v
+			continue
+		}
+		args = append(args, k, v)
+	}
+	// OTEL_SERVICE_NAME takes precedence over service.name in OTEL_RESOURCE_ATTRIBUTES.
+	if s := os.Getenv("OTEL_SERVICE_NAME"); s != "" {
+		service = s
+	}
+	if service != "" {
+		args = append([]any{"service.name", service}, args...)
+	}
+	SetResource(args...)
+}
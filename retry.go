@@ -0,0 +1,85 @@
+package slogf
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+//
+// RetryPolicy configures Retry().
+type RetryPolicy struct {
+	// Operation names what is retried in the records, e.g. "fetch prices".
+	Operation string
+	// Attempts is the maximum number of attempts, 3 when 0.
+	Attempts int
+	// Backoff is the wait after the first failed attempt, 100ms when 0.
+	Backoff time.Duration
+	// Multiplier grows the wait after every other failed attempt, 2 when 0.
+	Multiplier float64
+	// MaxBackoff caps the wait, none when 0.
+	MaxBackoff time.Duration
+}
+
+//
+// Retry() calls fn until it succeeds, the attempts are exhausted or ctx is done, logging every
+// attempt with the operation, attempt number and backoff so retries read the same in every service.
+// The level escalates with the attempts: DEBUG for the first failure, WARN for the next ones and
+// ERROR for the final one. A success after failures is logged at INFO. When ctx has a request ID,
+// every attempt gets a child of it, see ChildRequestID(), for fn and the records of the attempt.
+// E.g. err := Retry(ctx, RetryPolicy{Operation: "fetch prices", Attempts: 5}, fetchPrices)
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	if policy.Attempts <= 0 {
+		policy.Attempts = 3
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = 100 * time.Millisecond
+	}
+	if policy.Multiplier <= 0 {
+		policy.Multiplier = 2
+	}
+	backoff := policy.Backoff
+	parent := ctx
+	_, correlated := RequestIDFromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		if correlated {
+			ctx = ChildRequestID(parent)
+		}
+		err := fn(ctx)
+		attrs := []slog.Attr{
+			slog.String("operation", policy.Operation),
+			slog.Int("attempt", attempt),
+			slog.Int("attempts", policy.Attempts),
+		}
+		if err == nil {
+			if attempt > 1 {
+				LogAttrsDepth(ctx, 1, slog.LevelInfo, "retry succeeded", attrs...)
+			}
+			return nil
+		}
+		attrs = append(attrs, slog.String("error", err.Error()))
+		if attempt == policy.Attempts {
+			LogAttrsDepth(ctx, 1, slog.LevelError, "retry failed", attrs...)
+			return err
+		}
+		level := slog.LevelWarn
+		if attempt == 1 {
+			level = slog.LevelDebug
+		}
+		LogAttrsDepth(ctx, 1, level, "retry attempt failed", append(attrs, slog.Duration("backoff", backoff))...)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			LogAttrsDepth(ctx, 1, slog.LevelError, "retry aborted", attrs...)
+			return errors.Join(err, ctx.Err())
+		}
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
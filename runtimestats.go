@@ -0,0 +1,111 @@
+package slogf
+
+import (
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//
+// RuntimeStats() returns a "runtime" group attr with the GC pauses, heap, goroutines and open
+// file descriptors of the process, handy context alongside an error spike.
+// E.g. Error("Queue stalled.", RuntimeStats())
+func RuntimeStats() slog.Attr {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	attrs := []any{
+		slog.Uint64("heap_alloc", m.HeapAlloc),
+		slog.Uint64("heap_inuse", m.HeapInuse),
+		slog.Uint64("heap_objects", m.HeapObjects),
+		slog.Uint64("sys", m.Sys),
+		slog.Int("goroutines", runtime.NumGoroutine()),
+		slog.Group("gc",
+			slog.Uint64("count", uint64(m.NumGC)),
+			slog.Duration("last_pause", time.Duration(m.PauseNs[(m.NumGC+255)%256])),
+			slog.Duration("total_pause", time.Duration(m.PauseTotalNs)),
+		),
+	}
+	if fds, ok := openFDs(); ok {
+		attrs = append(attrs, slog.Int("fds", fds))
+	}
+	return slog.Group("runtime", attrs...)
+}
+
+//
+// StartRuntimeStats() emits an INFO record carrying RuntimeStats() every interval, a minute when
+// interval isn't positive. Call the returned function to stop it.
+func StartRuntimeStats(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	t := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				notice(slog.LevelInfo, "slogf: runtime stats", RuntimeStats())
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Stop()
+			close(done)
+		})
+	}
+}
+
+//
+// WithPeriodicRuntimeStats() emits RuntimeStats() every interval as StartRuntimeStats() does, for
+// as long as the settings apply, a later Init() without it stopping them. The interval is a minute
+// when it isn't positive.
+func WithPeriodicRuntimeStats(interval time.Duration) Option {
+	return func(s *settings) {
+		if interval <= 0 {
+			interval = defaultHeartbeatInterval
+		}
+		s.runtimeStats = interval
+	}
+}
+
+var (
+	periodicMu sync.Mutex
+	// periodicStop stops the stats of WithPeriodicRuntimeStats(), emitted every periodicInterval.
+	periodicStop     func()
+	periodicInterval time.Duration
+)
+
+//
+// setPeriodicRuntimeStats() starts the stats of WithPeriodicRuntimeStats() every interval, stopping
+// them when 0. Stats already emitted every interval keep running.
+func setPeriodicRuntimeStats(interval time.Duration) {
+	periodicMu.Lock()
+	defer periodicMu.Unlock()
+	if interval == periodicInterval {
+		return
+	}
+	if periodicStop != nil {
+		periodicStop()
+		periodicStop = nil
+	}
+	if interval > 0 {
+		periodicStop = StartRuntimeStats(interval)
+	}
+	periodicInterval = interval
+}
+
+//
+// openFDs() counts the open file descriptors where /proc is available.
+func openFDs() (int, bool) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	return len(entries), true
+}
@@ -0,0 +1,63 @@
+package slogf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer written by the goroutines of slogf while a test reads it.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
+
+func TestPeriodicRuntimeStats(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	var b lockedBuffer
+	if err := Reinit(WithFormat("json"), WithOutput(&b), WithPeriodicRuntimeStats(5*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(b.String(), `"msg":"slogf: runtime stats","runtime":{"heap_alloc":`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no runtime stats in %q", b.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Settings without the option stop them.
+	var after lockedBuffer
+	if err := Reinit(WithFormat("json"), WithOutput(&after)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if strings.Contains(after.String(), "runtime stats") {
+		t.Errorf("runtime stats after Reinit() without the option: %q", after.String())
+	}
+	if periodicStop != nil {
+		t.Error("the periodic stats are still running")
+	}
+
+	if err := Reinit(WithOutput(io.Discard), WithPeriodicRuntimeStats(0)); err != nil {
+		t.Fatal(err)
+	}
+	if periodicInterval != defaultHeartbeatInterval {
+		t.Errorf("interval 0 runs every %v, want %v", periodicInterval, defaultHeartbeatInterval)
+	}
+}
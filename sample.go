@@ -0,0 +1,76 @@
+package slogf
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// sampleRates are the rates of WithSampling() by level, nil when every record is kept.
+	sampleRates atomic.Pointer[map[slog.Level]float64]
+)
+
+//
+// WithSampling() keeps only a fraction of the records of the levels, picked at random, e.g.
+// WithSampling(map[slog.Level]float64{slog.LevelDebug: 0.01, slog.LevelInfo: 0.1}) for a chatty
+// service under load. The other levels and FATAL records are all kept. Rates are from 0 to 1.
+func WithSampling(rates map[slog.Level]float64) Option {
+	return func(s *settings) {
+		for level, rate := range rates {
+			if rate < 0 || rate > 1 {
+				s.errs = append(s.errs, fmt.Errorf("slogf: sampling rate %v of %s out of range, want 0 to 1", rate, levelLabel(level)))
+				return
+			}
+		}
+		s.sampling = make(map[slog.Level]float64, len(rates))
+		for level, rate := range rates {
+			s.sampling[level] = rate
+		}
+	}
+}
+
+//
+// sampledOut() tells whether r is dropped by the sampling.
+func sampledOut(r slog.Record) bool {
+	rates := sampleRates.Load()
+	if rates == nil || r.Level >= LevelFatal {
+		return false
+	}
+	rate, ok := (*rates)[r.Level]
+	return ok && rate < 1 && rand.Float64() >= rate
+}
+
+//
+// WithRedactKeys() renders the attrs of the keys as "[REDACTED]" in the output, in groups too, for
+// the sensitive values that aren't wrapped in Secret() at the call site. Keys are case insensitive.
+// E.g. WithRedactKeys("password", "authorization")
+func WithRedactKeys(keys ...string) Option {
+	return func(s *settings) {
+		for _, k := range keys {
+			if k == "" {
+				s.errs = append(s.errs, errors.New("slogf: empty redact key"))
+				return
+			}
+		}
+		s.redact = append(s.redact[:len(s.redact):len(s.redact)], keys...)
+	}
+}
+
+//
+// redactKeys() returns the ReplaceAttr function of WithRedactKeys().
+func redactKeys(keys []string) func(groups []string, a slog.Attr) slog.Attr {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = true
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if set[strings.ToLower(a.Key)] {
+			a.Value = slog.StringValue(Redacted)
+		}
+		return a
+	}
+}
@@ -0,0 +1,113 @@
+package slogf
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+)
+
+var (
+	attrSchemasMu sync.RWMutex
+	attrSchemas   = map[string]map[string]any{}
+)
+
+//
+// RegisterAttrSchema() declares the JSON Schema of the attribute key, so Schema() can describe it.
+// Packages with a fixed attr layout, like the events subpackage, register theirs at init.
+// E.g. RegisterAttrSchema("user_id", map[string]any{"type": "string"})
+func RegisterAttrSchema(key string, schema map[string]any) {
+	attrSchemasMu.Lock()
+	defer attrSchemasMu.Unlock()
+	attrSchemas[key] = schema
+}
+
+//
+// Schema() returns a JSON Schema describing the records written by the active configuration,
+// including the core fields, with their key names and their time, level and source formats, and
+// every attribute registered with RegisterAttrSchema().
+// Downstream teams can use it to validate log lines or generate parsers.
+func Schema() []byte {
+	b, _ := json.MarshalIndent(schema(), "", "  ")
+	return b
+}
+
+func schema() map[string]any {
+	settingsMu.Lock()
+	s := applied
+	settingsMu.Unlock()
+	key := func(k string) string {
+		if name, ok := s.keyNames[k]; ok {
+			return name
+		}
+		return k
+	}
+
+	// The labels of WithLevelLabels() may mix with the numbers of LevelFormatNumeric.
+	var levels, types []any
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelFatal} {
+		if l == slog.LevelDebug && !logDebug.Load() {
+			continue
+		}
+		v := levelValue(l)
+		t := "string"
+		if v.Kind() == slog.KindInt64 {
+			t = "integer"
+		}
+		if len(types) == 0 || (len(types) == 1 && types[0] != t) {
+			types = append(types, t)
+		}
+		levels = append(levels, v.Any())
+	}
+	level := map[string]any{"type": types[0], "enum": levels}
+	if len(types) > 1 {
+		level["type"] = types
+	}
+
+	timeSchema := map[string]any{"type": "string", "format": "date-time"}
+	switch s.timeFormat {
+	case TimeUnix:
+		timeSchema = map[string]any{"type": "integer", "description": "seconds since the epoch"}
+	case TimeUnixMillis:
+		timeSchema = map[string]any{"type": "integer", "description": "milliseconds since the epoch"}
+	}
+
+	properties := map[string]any{
+		key(slog.TimeKey):    timeSchema,
+		key(slog.LevelKey):   level,
+		key(slog.MessageKey): map[string]any{"type": "string"},
+	}
+	if s.addSource {
+		// The text handler writes the source as file:line, the JSON handler as an object.
+		source := map[string]any{
+			"type":        "string",
+			"description": "file:line of the call site",
+		}
+		if s.format != "text" && s.sourceFormat != SourceLine {
+			source = map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"function": map[string]any{"type": "string"},
+					"file":     map[string]any{"type": "string"},
+					"line":     map[string]any{"type": "integer"},
+				},
+			}
+		}
+		properties[key(slog.SourceKey)] = source
+	}
+	attrSchemasMu.RLock()
+	for k, v := range attrSchemas {
+		if _, ok := properties[k]; !ok {
+			properties[k] = v
+		}
+	}
+	attrSchemasMu.RUnlock()
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "slogf " + s.format + " record",
+		"type":                 "object",
+		"properties":           properties,
+		"required":             []any{key(slog.TimeKey), key(slog.LevelKey), key(slog.MessageKey)},
+		"additionalProperties": true,
+	}
+}
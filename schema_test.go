@@ -0,0 +1,73 @@
+package slogf
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	tests := []struct {
+		name  string
+		opts  []Option
+		check map[string]any // property => schema, nil when absent
+	}{
+		{"defaults", nil, map[string]any{
+			"time":   map[string]any{"type": "string", "format": "date-time"},
+			"level":  map[string]any{"type": "string", "enum": []any{"INFO", "WARN", "ERROR", "FATAL"}},
+			"msg":    map[string]any{"type": "string"},
+			"source": map[string]any{"type": "object", "properties": map[string]any{"function": map[string]any{"type": "string"}, "file": map[string]any{"type": "string"}, "line": map[string]any{"type": "integer"}}},
+		}},
+		{"renamed and formatted", []Option{WithKeyNames(KeyNames{Message: "message"}), WithTimeFormat(TimeUnixMillis), WithLevelFormat(LevelFormatLower), WithSourceFormat(SourceLine)}, map[string]any{
+			"msg":     nil,
+			"message": map[string]any{"type": "string"},
+			"time":    map[string]any{"type": "integer", "description": "milliseconds since the epoch"},
+			"level":   map[string]any{"type": "string", "enum": []any{"info", "warn", "error", "fatal"}},
+			"source":  map[string]any{"type": "string", "description": "file:line of the call site"},
+		}},
+		{"no source, numeric with labels", []Option{WithSource(false), WithLevel(slog.LevelDebug), WithLevelFormat(LevelFormatNumeric), WithLevelLabels(map[slog.Level]string{LevelFatal: "CRIT"})}, map[string]any{
+			"source": nil,
+			"level":  map[string]any{"type": []any{"integer", "string"}, "enum": []any{float64(-4), float64(0), float64(4), float64(8), "CRIT"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// WithLevel() first, the CI defaults would add DEBUG.
+			opts := append([]Option{WithLevel(slog.LevelInfo), WithOutput(io.Discard), WithFormat("json")}, tt.opts...)
+			if err := Reinit(opts...); err != nil {
+				t.Fatal(err)
+			}
+			var got struct {
+				Properties map[string]any `json:"properties"`
+				Required   []string       `json:"required"`
+			}
+			if err := json.Unmarshal(Schema(), &got); err != nil {
+				t.Fatal(err)
+			}
+			for key, want := range tt.check {
+				if !reflect.DeepEqual(got.Properties[key], want) {
+					t.Errorf("%s: got %v, want %v", key, got.Properties[key], want)
+				}
+			}
+			for _, key := range got.Required {
+				if got.Properties[key] == nil {
+					t.Errorf("required %s has no schema", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordToMapLevel(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	if err := Reinit(WithOutput(io.Discard), WithLevelLabels(map[slog.Level]string{slog.LevelWarn: "WARNING"})); err != nil {
+		t.Fatal(err)
+	}
+	r := slog.NewRecord(now(), slog.LevelWarn, "m", 0)
+	if got := RecordToMap(r)[slog.LevelKey]; got != "WARNING" {
+		t.Errorf("got level %v, want WARNING", got)
+	}
+}
@@ -0,0 +1,85 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+)
+
+//
+// Redacted is how a Secret() renders in every sink but the revealing ones.
+const Redacted = "[REDACTED]"
+
+type secret struct {
+	v any
+}
+
+func (s secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+//
+// Secret() wraps a sensitive value, e.g. a token, so it renders as "[REDACTED]" in the output,
+// except in sinks wrapped by RevealSecrets().
+// E.g. Info("Signed in.", "token", Secret(token))
+func Secret(v any) slog.LogValuer {
+	return secret{v: v}
+}
+
+type revealer struct {
+	next slog.Handler
+}
+
+//
+// RevealSecrets() wraps a sink, typically a local debug one added with AddSink(), so it gets the
+// actual values behind Secret() instead of "[REDACTED]".
+func RevealSecrets(h slog.Handler) slog.Handler {
+	return &revealer{next: h}
+}
+
+func (h *revealer) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *revealer) Handle(ctx context.Context, r slog.Record) error {
+	revealed := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		revealed.AddAttrs(reveal(a))
+		return true
+	})
+	return h.next.Handle(ctx, revealed)
+}
+
+func (h *revealer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	revealed := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		revealed[i] = reveal(a)
+	}
+	return &revealer{next: h.next.WithAttrs(revealed)}
+}
+
+func (h *revealer) WithGroup(name string) slog.Handler {
+	return &revealer{next: h.next.WithGroup(name)}
+}
+
+//
+// reveal() replaces the secrets in a, looking into groups, without resolving other values.
+func reveal(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindLogValuer:
+		if s, ok := a.Value.Any().(secret); ok {
+			return slog.Any(a.Key, s.v)
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, g := range group {
+			attrs[i] = reveal(g)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+	}
+	return a
+}
+
+func (h *revealer) unwrap() slog.Handler {
+	return h.next
+}
@@ -1,4 +1,4 @@
-// 
+//
 // slogf sets up a logger based on Go's slog package at 1.21.
 //
 // Idea is to switch to a structural logging that gives key and value at the same time.
@@ -38,143 +38,140 @@
 package slogf
 
 import (
-	"strings"
 	"context"
-	"fmt"
+	"errors"
+	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"runtime"
-	"time"
-	"log/slog"
+	"sync"
+	"sync/atomic"
 )
 
 var (
-	Logger *slog.Logger
+	// Logger is the global logger. Loggers derived from it, even before Init(), use the pipeline
+	// set up last, or a default one, JSON at INFO level on stdout, until Init() is called.
+	Logger = slog.New(&handler{})
+
+	// Settings of the last InitLogging() call, read by the handlers while Init() may run again.
+	logDebug   atomic.Bool
+	textFormat atomic.Bool
+	// skipSource saves the runtime.Callers() of every record when the source is off.
+	skipSource atomic.Bool
 )
 
 const (
-	LevelFatal	= slog.Level(12)
+	LevelFatal = slog.Level(12)
 )
 
 //
-// Actual logging in different levels.
-//
-// Debug() wraps around slog.Debug()
-func Debug(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelDebug) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelDebug, format, pcs[0])
-	r.Add(args...)
-	_ = Logger.Handler().Handle(context.Background(), r)
-}
-//
-// Debugf() provides flexibility to log with the 'printf' style
-func Debugf(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelDebug) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelDebug, fmt.Sprintf(format, args...), pcs[0])
-	_ = Logger.Handler().Handle(context.Background(), r)
-}
+// Actual logging in different levels. Debug() and Debugf() live in debug.go.
 //
 // Info() wraps around slog.Info()
 func Info(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelInfo) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelInfo, format, pcs[0])
-	r.Add(args...)
-	_ = Logger.Handler().Handle(context.Background(), r)
+	log(context.Background(), slog.LevelInfo, format, args)
 }
+
 //
 // Infof() provides flexibility to log with the 'printf' style
 func Infof(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelInfo) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelInfo, fmt.Sprintf(format, args...), pcs[0])
-	_ = Logger.Handler().Handle(context.Background(), r)
+	logf(context.Background(), slog.LevelInfo, format, args)
 }
+
 //
 // Warn() wraps around slog.Warn()
 func Warn(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelWarn) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelWarn, format, pcs[0])
-	r.Add(args...)
-	_ = Logger.Handler().Handle(context.Background(), r)
+	log(context.Background(), slog.LevelWarn, format, args)
 }
+
 //
 // Warnf() provides flexibility to log with the 'printf' style
 func Warnf(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelWarn) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelWarn, fmt.Sprintf(format, args...), pcs[0])
-	_ = Logger.Handler().Handle(context.Background(), r)
+	logf(context.Background(), slog.LevelWarn, format, args)
 }
+
 //
 // Error() wraps around slog.Error()
 func Error(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelError) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelError, format, pcs[0])
-	r.Add(args...)
-	_ = Logger.Handler().Handle(context.Background(), r)
+	log(context.Background(), slog.LevelError, format, args)
 }
+
 //
 // Errorf() provides flexibility to log with the 'printf' style
 func Errorf(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), slog.LevelError) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), slog.LevelError, fmt.Sprintf(format, args...), pcs[0])
-	_ = Logger.Handler().Handle(context.Background(), r)
+	logf(context.Background(), slog.LevelError, format, args)
 }
+
 //
 // Fatal() exits the main program.
 func Fatal(format string, args ...any) {
-//func Fatal(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), LevelFatal) {
-		return
-	}
-	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), LevelFatal, format, pcs[0])
-	r.Add(args...)
-	_ = Logger.Handler().Handle(context.Background(), r)
-	os.Exit(1)
+	log(context.Background(), LevelFatal, format, args)
+	exit(format)
 }
+
 //
 // Fatalf() provides flexibility to log with the 'printf' style
 func Fatalf(format string, args ...any) {
-	if !Logger.Enabled(context.Background(), LevelFatal) {
+	logf(context.Background(), LevelFatal, format, args)
+	exit(sprintf(format, args))
+}
+
+//
+// log() is the common path of the key value style functions.
+func log(ctx context.Context, level slog.Level, msg string, args []any) {
+	if !Logger.Enabled(ctx, level) {
+		if ring := debugRing.Load(); ring != nil && level < slog.LevelInfo {
+			ring.keep(level, msg, args)
+		}
+		return
+	}
+	emit(ctx, 2+int(callerSkip.Load()), level, msg, args) // skip [log, Info]
+}
+
+//
+// logf() is the common path of the 'printf' style functions.
+func logf(ctx context.Context, level slog.Level, format string, args []any) {
+	if !Logger.Enabled(ctx, level) {
+		if ring := debugRing.Load(); ring != nil && level < slog.LevelInfo {
+			ring.keep(level, sprintf(format, args), nil)
+		}
 		return
 	}
+	if formatCheck.Load() {
+		if verbs, ok := countVerbs(format); ok && verbs != len(args) {
+			emit(ctx, 2+int(callerSkip.Load()), slog.LevelWarn, "slogf: format verb count mismatch", []any{"format", format, "verbs", verbs, "args", len(args)})
+		}
+	}
+	emit(ctx, 2+int(callerSkip.Load()), level, sprintf(format, args), nil) // skip [logf, Infof]
+}
+
+//
+// LogAttrsDepth() logs a record with typed attributes. It is the entry point for code that wraps
+// slogf, such as the functions generated by slogf-gen.
+// depth is the number of stack frames to skip above the caller of LogAttrsDepth() when reporting the
+// source, 0 being the caller itself and 1 the caller of a wrapper.
+func LogAttrsDepth(ctx context.Context, depth int, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !Logger.Enabled(ctx, level) {
+		return
+	}
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	emit(ctx, depth+1, level, msg, args) // skip [LogAttrsDepth]
+}
+
+//
+// emit() builds the record and hands it to the handler.
+// skip is the number of frames between the caller of emit() and the reported source.
+func emit(ctx context.Context, skip int, level slog.Level, msg string, args []any) {
 	var pcs [1]uintptr
-	runtime.Callers(2, pcs[:]) // skip [Callers, Infof]
-	r := slog.NewRecord(time.Now(), LevelFatal, fmt.Sprintf(format, args...), pcs[0])
-	_ = Logger.Handler().Handle(context.Background(), r)
-	os.Exit(1)
+	if !skipSource.Load() {
+		runtime.Callers(skip+2, pcs[:]) // skip [Callers, emit]
+	}
+	r := slog.NewRecord(now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = Logger.Handler().Handle(ctx, r)
 }
 
 //
@@ -182,36 +179,284 @@ func Fatalf(format string, args ...any) {
 // debug = true: DEBUG level displays DEBUG, INFO, WARN, ERROR, FATAL logs.
 //
 // InitLogging() wraps around a new global logger with level and format.
+// It is Init(WithDebug(debug), WithFormat(format)), kept for the existing callers, leaving the
+// CI defaults to a false debug and an empty format.
 func InitLogging(debug bool, format string) {
+	Init(loggingOptions(debug, format)...)
+}
+
+//
+// InitLoggingE() is InitLogging() returning an error for an unknown format instead of using JSON.
+func InitLoggingE(debug bool, format string) error {
+	return InitE(loggingOptions(debug, format)...)
+}
+
+func loggingOptions(debug bool, format string) []Option {
+	if !ciDefaults() {
+		return []Option{WithDebug(debug), WithFormat(format)}
+	}
+	var opts []Option
+	if debug {
+		opts = append(opts, WithDebug(true))
+	}
+	if format != "" {
+		opts = append(opts, WithFormat(format))
+	}
+	return opts
+}
+
+//
+// InitWithHandler() sets up the global logger as Init() does with h writing the records, e.g. a
+// vendor handler behind the Debug() to Fatalf() functions of slogf.
+// E.g. InitWithHandler(datadog.NewHandler(client))
+func InitWithHandler(h slog.Handler, opts ...Option) error {
+	return InitE(append(opts, WithHandler(h))...)
+}
+
+//
+// InitE() is Init() returning an error for invalid options, the logger is then left as it was.
+// E.g. InitE(WithFormat(os.Getenv("LOG_FORMAT"))) fails at startup for LOG_FORMAT=xml
+func InitE(opts ...Option) error {
+	s := defaultSettings()
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if err := errors.Join(s.errs...); err != nil {
+		return err
+	}
+	return setup(s, false, nil)
+}
+
+//
+// Init() sets up the global logger with the options, e.g.
+// Init(WithLevel(slog.LevelWarn), WithFormat("text"), WithOutput(os.Stderr))
+// Each option has a default, JSON at INFO level on stdout with the source file base name.
+// Under CI it uses the text format at debug level with full source paths, see SetCIDetection().
+// Invalid options are ignored, keeping their defaults, and reported by a WARN record.
+// Init() can be called again, concurrently too, to reconfigure, unless SetInitOnce() is on.
+func Init(opts ...Option) {
+	s := defaultSettings()
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if setup(s, false, nil) != nil {
+		return
+	}
+	if len(s.errs) > 0 {
+		notice(slog.LevelWarn, "slogf: invalid configuration", slog.String("error", errors.Join(s.errs...).Error()))
+	}
+}
+
+var (
+	defaultOnce sync.Once
+	// initOnce makes the Init() calls after the first one no-ops, initialized tells there was one.
+	initOnce    atomic.Bool
+	initialized bool
+)
+
+//
+// ErrInitialized is returned by InitE() and the other Init functions after the first one when
+// SetInitOnce() is on.
+var ErrInitialized = errors.New("slogf: already initialized, use Reinit() to reconfigure")
+
+//
+// SetInitOnce() makes only the first Init(), InitLogging() or InitFrom*() call set up the logger
+// when on, so a library or a test helper calling it again can't undo the configuration of main.
+// Reinit() still reconfigures it.
+func SetInitOnce(on bool) {
+	initOnce.Store(on)
+}
+
+//
+// Reinit() sets up the global logger with the options as InitE() does, even when SetInitOnce() is
+// on. Loggers derived from Logger switch to the new configuration atomically.
+func Reinit(opts ...Option) error {
+	s := defaultSettings()
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if err := errors.Join(s.errs...); err != nil {
+		return err
+	}
+	return setup(s, true, nil)
+}
+
+//
+// defaultPipeline() sets up the default logger, JSON at INFO level on stdout, on the first record
+// logged before any Init(), so libraries logging before main initializes don't crash or get lost.
+func defaultPipeline() *pipeline {
+	defaultOnce.Do(func() {
+		settingsMu.Lock()
+		defer settingsMu.Unlock()
+		if current.Load() == nil {
+			applySettings(defaultSettings())
+		}
+	})
+	return current.Load()
+}
+
+//
+// setup() installs the global logger of the settings, unless SetInitOnce() is on and it was done
+// and force is false. prepare, when not nil, runs right before with settingsMu held.
+func setup(s settings, force bool, prepare func()) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if !force && initOnce.Load() && initialized {
+		return ErrInitialized
+	}
+	initialized = true
+	if prepare != nil {
+		prepare()
+	}
+	applySettings(s)
+	return nil
+}
+
+//
+// reconfigure() sets up the global logger again with the settings last applied changed by opt.
+func reconfigure(opt Option) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	s := applied
+	s.errs, s.inherited = nil, false
+	opt(&s)
+	if err := errors.Join(s.errs...); err != nil {
+		return err
+	}
+	applySettings(s)
+	return nil
+}
+
+//
+// applySettings() installs the global logger of the settings, with settingsMu held.
+func applySettings(s settings) {
+	applied = s
+	sourceFormat.Store(s.sourceFormat)
+	skipSource.Store(!s.addSource)
+	callerSkip.Store(int32(s.callerSkip))
+	fatalFlushTimeout.Store(int64(s.fatalFlushTimeout))
+	fatalPanic.Store(s.fatalPanic)
+	if s.clock != nil {
+		clock.Store(&s.clock)
+	} else {
+		clock.Store(nil)
+	}
+	if (s.levelFormat != "" && s.levelFormat != LevelFormatUpper) || len(s.levelLabels) > 0 {
+		levelRendering.Store(&levelRender{format: s.levelFormat, labels: s.levelLabels})
+	} else {
+		levelRendering.Store(nil)
+	}
+	timeFormat.Store(s.timeFormat)
+	timeZone.Store(s.timeZone)
+	logDebug.Store(s.level <= slog.LevelDebug)
+	emittedAt.Store(s.emittedAt)
+	textFormat.Store(s.format == "text")
+
+	hopts := &slog.HandlerOptions{AddSource: s.addSource, Level: s.level, ReplaceAttr: replaceAttr}
+	if len(s.sampling) > 0 {
+		sampleRates.Store(&s.sampling)
+	} else {
+		sampleRates.Store(nil)
+	}
+	fns := s.replaceAttrs
+	if len(s.redact) > 0 {
+		fns = append([]func(groups []string, a slog.Attr) slog.Attr{redactKeys(s.redact)}, fns...)
+	}
+	if len(s.keyNames) > 0 {
+		fns = append(fns[:len(fns):len(fns)], renameKeys(s.keyNames))
+	}
+	setRenamedKeys(s.keyNames)
+	if len(fns) > 0 {
+		hopts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+			a = replaceAttr(groups, a)
+			for _, fn := range fns {
+				if a.Equal(slog.Attr{}) {
+					break
+				}
+				a = fn(groups, a)
+			}
+			return a
+		}
+	}
 
-	replace := func(groups []string, a slog.Attr) slog.Attr {
-		if a.Key == slog.SourceKey {
-			source := a.Value.Any().(*slog.Source)
-			source.File = filepath.Base(source.File)
+	n := int(shards.Load())
+	// The sharded output stamps the lines it writes out, before their checksum.
+	var stamp func(line []byte) []byte
+	if s.emittedAt && n > 1 {
+		stamp = func(line []byte) []byte {
+			line = appendEmittedAt(line, now())
+			if s.checksum {
+				line = appendChecksum(line)
+			}
+			return line
+		}
+	}
+	newHandler := func(w io.Writer) slog.Handler {
+		if s.checksum && stamp == nil {
+			w = &checksumWriter{w: w}
+		}
+		if f, ok := lookupFormat(s.format); ok {
+			return f(w, hopts)
 		}
-		
-		// Adding a whole new level as Fatal
-		if a.Key == slog.LevelKey {
-			a.Key = "level"
-			level := a.Value.Any().(slog.Level)
-			if level == LevelFatal {
-				a.Value = slog.StringValue("FATAL")
+		if s.format == "text" {
+			if s.foldMarker != "" && !s.checksum && stamp == nil {
+				w = &foldWriter{w: w, marker: s.foldMarker}
 			}
+			return slog.NewTextHandler(w, hopts)
 		}
+		return slog.NewJSONHandler(w, hopts)
+	}
+	outputMu.Lock()
+	output = s.output
+	outputMu.Unlock()
+	sinksMu.Lock()
+	static = s.static
+	sinksMu.Unlock()
+	if s.handler != nil {
+		logDebug.Store(s.handler.Enabled(context.Background(), slog.LevelDebug))
+		textFormat.Store(false)
+		skipSource.Store(false)
+		install(s.handler)
+	} else if n > 1 {
+		install(newShardedHandler(s.output, n, newHandler, stamp))
+	} else {
+		install(newHandler(s.output))
+	}
+	setPeriodicRuntimeStats(s.runtimeStats)
+	if s.inherited {
+		notice(slog.LevelInfo, "slogf: output inherited", slog.Int("parent_pid", os.Getppid()))
+	}
+}
+
+//
+// replaceAttr() shows the source in the source format, formats the time and the level, labeling
+// FATAL.
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	// Attrs of the caller may use the same keys, inside groups or with other types.
+	if len(groups) > 0 {
 		return a
 	}
+	if a.Key == slog.SourceKey {
+		if source, ok := a.Value.Any().(*slog.Source); ok {
+			if source.File == "" {
+				// Records of slogf itself have no call site.
+				return slog.Attr{}
+			}
+			a.Value = sourceValue(source)
+		}
+	}
 
-	if debug == true {
-		if strings.ToLower(format) == "text" {
-			Logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug, ReplaceAttr: replace}))
-		} else {
-			Logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug, ReplaceAttr: replace}))
+	if a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+		if v, ok := formatTime(a.Value.Time()); ok {
+			a.Value = v
+		}
+	}
+
+	// Adding a whole new level as Fatal, and the level format.
+	if a.Key == slog.LevelKey {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			a.Value = levelValue(level)
 		}
-	} else {
-		if strings.ToLower(format) == "text" {
-			Logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: slog.LevelInfo, ReplaceAttr: replace}))
-		} else {
-			Logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: slog.LevelInfo, ReplaceAttr: replace}))
-		}		
 	}
+	return a
 }
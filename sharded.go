@@ -0,0 +1,234 @@
+package slogf
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	shards atomic.Int32
+)
+
+const (
+	// A shard is written out once it holds shardFlushSize bytes, or every shardFlushInterval.
+	shardFlushSize     = 64 << 10
+	shardFlushInterval = 10 * time.Millisecond
+	// Past shardMaxSize bytes, a record waits for its shard to be written out.
+	shardMaxSize = 4 << 20
+)
+
+//
+// SetSharding() spreads the output of the next InitLogging() over n buffers, each with its own
+// handler and lock, merged into stdout by a single writer goroutine. It removes the single writer
+// mutex bottleneck of very chatty multi-core services, at the cost of records of different
+// goroutines being written slightly out of order. ERROR and FATAL records take a lane of their
+// own, written out right away and ahead of the other shards, so they aren't delayed by a flood of
+// DEBUG and INFO records. 0 or 1 turns it off.
+// E.g. SetSharding(runtime.GOMAXPROCS(0))
+func SetSharding(n int) {
+	shards.Store(int32(n))
+}
+
+type shard struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+//
+// shardedOutput is the writer goroutine and the buffers of the shards.
+type shardedOutput struct {
+	w      io.Writer
+	wmu    sync.Mutex
+	shards []*shard
+	// urgent is the lane of the ERROR and FATAL records, which don't count in queued.
+	urgent *shard
+	kick   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	next   atomic.Uint32
+	queued atomic.Int64
+	// stamp finishes a line as it is written out, see WithEmittedAt(), nil when lines are as is.
+	stamp func(line []byte) []byte
+
+	removeGauge func()
+}
+
+type shardedHandler struct {
+	out    *shardedOutput
+	hs     []slog.Handler
+	urgent slog.Handler
+}
+
+func newShardedHandler(w io.Writer, n int, newHandler func(w io.Writer) slog.Handler, stamp func(line []byte) []byte) *shardedHandler {
+	out := &shardedOutput{w: w, urgent: &shard{}, kick: make(chan struct{}, 1), done: make(chan struct{}), stamp: stamp}
+	h := &shardedHandler{out: out, urgent: newHandler(shardWriter{out: out, s: out.urgent, urgent: true})}
+	for i := 0; i < n; i++ {
+		s := &shard{}
+		out.shards = append(out.shards, s)
+		h.hs = append(h.hs, newHandler(shardWriter{out: out, s: s}))
+	}
+	go out.run()
+	out.removeGauge = addPressureGauge(func() float64 {
+		return float64(out.queued.Load()) / float64(n*shardMaxSize)
+	})
+	return h
+}
+
+func (h *shardedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.hs[0].Enabled(ctx, level)
+}
+
+func (h *shardedHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		return h.urgent.Handle(ctx, r)
+	}
+	i := h.out.next.Add(1) % uint32(len(h.hs))
+	return h.hs[i].Handle(ctx, r)
+}
+
+func (h *shardedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make([]slog.Handler, len(h.hs))
+	for i, x := range h.hs {
+		hs[i] = x.WithAttrs(attrs)
+	}
+	return &shardedHandler{out: h.out, hs: hs, urgent: h.urgent.WithAttrs(attrs)}
+}
+
+func (h *shardedHandler) WithGroup(name string) slog.Handler {
+	hs := make([]slog.Handler, len(h.hs))
+	for i, x := range h.hs {
+		hs[i] = x.WithGroup(name)
+	}
+	return &shardedHandler{out: h.out, hs: hs, urgent: h.urgent.WithGroup(name)}
+}
+
+//
+// Flush() writes out every shard.
+func (h *shardedHandler) Flush() error {
+	return h.out.flush()
+}
+
+//
+// Close() stops the writer goroutine after writing out every shard.
+func (h *shardedHandler) Close() error {
+	h.out.once.Do(func() {
+		close(h.out.done)
+		h.out.removeGauge()
+	})
+	return h.out.flush()
+}
+
+type shardWriter struct {
+	out    *shardedOutput
+	s      *shard
+	urgent bool
+}
+
+func (w shardWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.out.done:
+		// Closed, e.g. records logged after Shutdown(), write through.
+		w.out.wmu.Lock()
+		defer w.out.wmu.Unlock()
+		if _, err := w.out.w.Write(w.out.finish(p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	default:
+	}
+	if w.urgent {
+		// Not behind the backpressure of the other shards, and written out right away.
+		w.s.mu.Lock()
+		w.s.buf.Write(p)
+		w.s.mu.Unlock()
+		w.out.wake()
+		return len(p), nil
+	}
+	// Backpressure: wait for the writer rather than growing without bound.
+	if w.out.queued.Load() > int64(len(w.out.shards)*shardMaxSize) {
+		if err := w.out.flush(); err != nil {
+			return 0, err
+		}
+	}
+	w.s.mu.Lock()
+	w.s.buf.Write(p)
+	full := w.s.buf.Len() >= shardFlushSize
+	w.s.mu.Unlock()
+	w.out.queued.Add(int64(len(p)))
+	if full {
+		w.out.wake()
+	}
+	return len(p), nil
+}
+
+//
+// wake() has the writer goroutine write the shards out now.
+func (o *shardedOutput) wake() {
+	select {
+	case o.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (o *shardedOutput) run() {
+	t := time.NewTicker(shardFlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-o.kick:
+		case <-o.done:
+			return
+		}
+		if err := o.flush(); err != nil {
+			sinkErrors.Add(1)
+		}
+	}
+}
+
+//
+// flush() writes the shards out one after the other, each shard's records in order, the urgent
+// lane first.
+func (o *shardedOutput) flush() error {
+	o.wmu.Lock()
+	defer o.wmu.Unlock()
+	var pending bytes.Buffer
+	var err error
+	o.urgent.mu.Lock()
+	pending.Write(o.urgent.buf.Bytes())
+	o.urgent.buf.Reset()
+	o.urgent.mu.Unlock()
+	urgent := pending.Len()
+	for _, s := range o.shards {
+		s.mu.Lock()
+		pending.Write(s.buf.Bytes())
+		s.buf.Reset()
+		s.mu.Unlock()
+	}
+	if pending.Len() > 0 {
+		o.queued.Add(-int64(pending.Len() - urgent))
+		_, err = o.w.Write(o.finish(pending.Bytes()))
+	}
+	return err
+}
+
+//
+// finish() returns the records of p, whole lines, stamped by stamp.
+func (o *shardedOutput) finish(p []byte) []byte {
+	if o.stamp == nil {
+		return p
+	}
+	out := make([]byte, 0, len(p)+len(p)/4)
+	for len(p) > 0 {
+		line, rest, _ := bytes.Cut(p, []byte("\n"))
+		out = append(out, o.stamp(line)...)
+		out = append(out, '\n')
+		p = rest
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package slogf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Flusher is implemented by sinks that buffer records, like the LokiHandler.
+type Flusher interface {
+	Flush() error
+}
+
+var (
+	shutdownNotified atomic.Bool
+	// exitFunc replaces os.Exit() after a FATAL record, nil for os.Exit().
+	exitFunc atomic.Pointer[func(code int)]
+	// fatalFlushTimeout bounds the flush of the sinks after a FATAL record, see WithFatalFlushTimeout().
+	fatalFlushTimeout atomic.Int64
+	fatalPanic        atomic.Bool
+)
+
+const defaultFatalFlushTimeout = 5 * time.Second
+
+func init() {
+	fatalFlushTimeout.Store(int64(defaultFatalFlushTimeout))
+}
+
+//
+// WithFatalFlushTimeout() bounds how long Fatal() waits for the sinks to be flushed before exiting,
+// 5s by default, so a sink stuck on the network can't keep a failing program up.
+func WithFatalFlushTimeout(d time.Duration) Option {
+	return func(s *settings) {
+		if d <= 0 {
+			s.errs = append(s.errs, fmt.Errorf("slogf: fatal flush timeout %v, want a positive duration", d))
+			return
+		}
+		s.fatalFlushTimeout = d
+	}
+}
+
+//
+// NotifyShutdown() logs the final "shutting down" record of the program, with the reason, the
+// function initiating the shutdown and the process uptime, plus the extra key value pairs.
+// Only the first call logs, so every service ends with exactly one such record: FlushOnSignal()
+// logs it with the signal name, a Fatal() with initiator "fatal". A program killed, e.g. by the OOM
+// killer, has none.
+// E.g. NotifyShutdown("deploy", "version", version)
+func NotifyShutdown(reason string, args ...any) {
+	initiator := "unknown"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			initiator = fn.Name()
+		}
+	}
+	notifyShutdown(reason, initiator, args...)
+}
+
+func notifyShutdown(reason, initiator string, args ...any) {
+	if shutdownNotified.Swap(true) {
+		return
+	}
+	breadcrumb(slog.LevelInfo, "shutting down", append([]any{
+		slog.String("reason", reason),
+		slog.String("initiator", initiator),
+		slog.Duration("uptime", time.Since(startTime).Round(time.Millisecond)),
+	}, args...)...)
+}
+
+//
+// Shutdown() logs a final summary record, with the process uptime, the records per level, the
+// dropped records and the sink errors, then closes the sinks added with AddSink(), flushing them.
+// Call it once when the program ends, e.g. defer Shutdown() in main.
+func Shutdown() error {
+	summarize()
+	return closeSinks(true)
+}
+
+//
+// SetExitFunc() makes Fatal() and Fatalf() call fn with the exit code 1 instead of os.Exit(), once
+// the sinks are flushed, so tests can check them and daemons can route fatal exits through their
+// own shutdown path. Fatal() returns if fn does. nil goes back to os.Exit().
+// E.g. SetExitFunc(func(code int) { exited = code })
+func SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		exitFunc.Store(nil)
+		return
+	}
+	exitFunc.Store(&fn)
+}
+
+//
+// FatalError is the panic value of Fatal() and the other FATAL functions with WithFatalPanic().
+type FatalError struct {
+	// Message is the message of the FATAL record.
+	Message string
+}
+
+func (e *FatalError) Error() string {
+	return "slogf: fatal: " + e.Message
+}
+
+//
+// WithFatalPanic() makes Fatal(), Fatalf(), Must() and the other FATAL functions panic with a
+// *FatalError once the sinks are flushed, instead of exiting, so the deferred cleanups run and a
+// test harness or the larger process embedding a library can recover. The program keeps running
+// then, so no shutdown record is logged.
+// E.g.
+//
+//	defer func() {
+//		if fe, ok := recover().(*FatalError); ok {
+//			err = fe
+//		}
+//	}()
+func WithFatalPanic(on bool) Option {
+	return func(s *settings) { s.fatalPanic = on }
+}
+
+//
+// exit() ends the program after a FATAL record of msg, with the same breadcrumb as Shutdown(), once
+// the sharded output, the batches of the sinks and the output file are flushed or the fatal flush
+// timeout is over. It panics instead with WithFatalPanic().
+func exit(msg string) {
+	panicking := fatalPanic.Load()
+	if !panicking {
+		notifyShutdown("fatal", "fatal")
+		summarize()
+	}
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		_ = flushNow()
+	}()
+	timeout := time.Duration(fatalFlushTimeout.Load())
+	select {
+	case <-flushed:
+	case <-time.After(timeout):
+		fmt.Fprintf(os.Stderr, "slogf: sinks not flushed within the fatal flush timeout of %v\n", timeout)
+	}
+	if panicking {
+		panic(&FatalError{Message: msg})
+	}
+	if fn := exitFunc.Load(); fn != nil {
+		(*fn)(1)
+		return
+	}
+	os.Exit(1)
+}
+
+func summarize() {
+	// Stop the summarizer first so its last error_summary makes it into the counts.
+	if s := summarizer.Swap(nil); s != nil {
+		s.stop()
+	}
+	breadcrumb(slog.LevelInfo, "slogf: shutdown",
+		slog.Duration("uptime", time.Since(startTime).Round(time.Millisecond)),
+		pipelineStats(),
+	)
+}
+
+//
+// closeSinks() closes, or only flushes when closing is false, every sink able to.
+func closeSinks(closing bool) error {
+	sinksMu.Lock()
+	hs := append([]slog.Handler{base}, sinks...)
+	hs = append(hs, configSinks...)
+	sinksMu.Unlock()
+	var errs []error
+	for _, h := range hs {
+		// Look through the wrappers like RevealSecrets() and WithView().
+		for {
+			u, ok := h.(interface{ unwrap() slog.Handler })
+			if !ok {
+				break
+			}
+			_, closer := h.(io.Closer)
+			_, flusher := h.(Flusher)
+			if closer || flusher {
+				break
+			}
+			h = u.unwrap()
+		}
+		if c, ok := h.(io.Closer); ok && closing {
+			errs = append(errs, c.Close())
+		} else if f, ok := h.(Flusher); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package slogf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	sinksMu sync.Mutex
+	// base is the format handler set up by InitLogging(), sinks the extra ones from AddSink() and
+	// configSinks the ones of the config, replaced by every InitFromConfig().
+	base        slog.Handler
+	sinks       []slog.Handler
+	configSinks []slog.Handler
+	// replacedSinks are the previous configSinks, closed by the next install().
+	replacedSinks []slog.Handler
+	// static are the fields of WithStaticFields().
+	static []slog.Attr
+	// current is the pipeline the handler of every logger passes the records to.
+	current atomic.Pointer[pipeline]
+)
+
+//
+// pipeline is the format handler with the sinks and the resource, as last built by rebuild().
+type pipeline struct {
+	next slog.Handler
+	// inflight counts the records being handled, retired is set once the pipeline is replaced.
+	inflight atomic.Int64
+	retired  atomic.Bool
+}
+
+//
+// drainTimeout bounds how long a replaced pipeline waits for its records in flight.
+const drainTimeout = 5 * time.Second
+
+//
+// drain() waits for the records in flight in p once it is replaced, so closing its sinks doesn't
+// lose them. Records logged from then on go to the new pipeline.
+func (p *pipeline) drain() {
+	p.retired.Store(true)
+	deadline := time.Now().Add(drainTimeout)
+	for p.inflight.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+//
+// currentPipeline() returns the pipeline records go to, setting up the default one before Init().
+func currentPipeline() *pipeline {
+	if p := current.Load(); p != nil {
+		return p
+	}
+	return defaultPipeline()
+}
+
+//
+// AddSink() adds a handler that receives every record next to the one set up by InitLogging(),
+// e.g. a local debug file next to stdout. The sink's own Enabled() decides what it gets.
+// Loggers derived from Logger before the call get the sink too.
+func AddSink(h slog.Handler) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(sinks, h)
+	rebuild()
+}
+
+//
+// install() makes h the format handler of the global Logger.
+func install(h slog.Handler) {
+	sinksMu.Lock()
+	old, prev := base, current.Load()
+	base = h
+	rebuild()
+	sinksMu.Unlock()
+	if prev != nil {
+		prev.drain()
+	}
+	closeHandlers(oldConfigSinks())
+	// The previous one may hold a writer goroutine and buffered records, like the sharded output.
+	// A handler of WithHandler() is installed again as is by the runtime setters.
+	if c, ok := old.(io.Closer); ok && !(reflect.TypeOf(old).Comparable() && old == h) {
+		_ = c.Close()
+	}
+}
+
+//
+// stageConfigSinks() makes hs the sinks of the config from the next install(), which closes the
+// previous ones once drained.
+func stageConfigSinks(hs []slog.Handler) {
+	sinksMu.Lock()
+	replacedSinks = append(replacedSinks, configSinks...)
+	configSinks = hs
+	sinksMu.Unlock()
+}
+
+func oldConfigSinks() []slog.Handler {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	old := replacedSinks
+	replacedSinks = nil
+	return old
+}
+
+func rebuild() {
+	if base == nil {
+		return
+	}
+	next := base
+	if len(sinks)+len(configSinks) > 0 {
+		hs := append([]slog.Handler{base}, sinks...)
+		next = Fanout(append(hs, configSinks...)...)
+	}
+	if len(resource) > 0 {
+		next = next.WithAttrs(resource)
+	}
+	if len(static) > 0 {
+		next = next.WithAttrs(static)
+	}
+	current.Store(&pipeline{next: next})
+}
+
+type fanout struct {
+	hs []slog.Handler
+}
+
+//
+// Fanout() returns a handler passing every record to all of hs that are enabled for its level,
+// or to all of them for a record a LevelResolver or a Target() rule enabled.
+// Values are not resolved on the way, so each sink resolves every slog.LogValuer exactly once,
+// by itself, and a Secret() can render differently per sink.
+func Fanout(hs ...slog.Handler) slog.Handler {
+	return &fanout{hs: hs}
+}
+
+func (f *fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f.hs {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f.hs {
+		if !h.Enabled(ctx, r.Level) && !forcedLevel(ctx) {
+			continue
+		}
+		// Each sink gets its own copy so one adding attrs can't affect another.
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make([]slog.Handler, len(f.hs))
+	for i, h := range f.hs {
+		hs[i] = h.WithAttrs(attrs)
+	}
+	return &fanout{hs: hs}
+}
+
+func (f *fanout) WithGroup(name string) slog.Handler {
+	hs := make([]slog.Handler, len(f.hs))
+	for i, h := range f.hs {
+		hs[i] = h.WithGroup(name)
+	}
+	return &fanout{hs: hs}
+}
@@ -0,0 +1,13 @@
+module github.com/keithshum/slogf/slogfcobra
+
+go 1.21.0
+
+replace github.com/keithshum/slogf => ../
+
+require (
+	github.com/keithshum/slogf v0.0.0-00010101000000-000000000000
+	github.com/spf13/cobra v1.10.2
+	github.com/spf13/pflag v1.0.10
+)
+
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
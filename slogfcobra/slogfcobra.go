@@ -0,0 +1,71 @@
+//
+// slogfcobra wires slogf to cobra-based CLIs in one call.
+//
+// It lives in its own module to keep pflag and cobra out of slogf. Way to use:
+//
+//   root := &cobra.Command{Use: "app"}
+//   slogfcobra.Setup(root)
+//
+// Every command then accepts --debug, --log-format and --log-output, and slogf is initialized
+// before it runs.
+//
+
+package slogfcobra
+
+import (
+	"github.com/keithshum/slogf"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+//
+// Flags are the slogf settings bound to a flag set by BindFlags().
+type Flags struct {
+	Debug  bool
+	Format string
+	Output string
+}
+
+//
+// BindFlags() registers --debug, --log-format and --log-output on fs.
+func BindFlags(fs *pflag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.BoolVar(&f.Debug, "debug", false, "log at debug level")
+	fs.StringVar(&f.Format, "log-format", "json", "log format: text or json")
+	fs.StringVar(&f.Output, "log-output", "stdout", "log output: stdout, stderr or a file path")
+	return f
+}
+
+//
+// Init() sets up slogf from the parsed flags.
+func (f *Flags) Init() error {
+	level := "info"
+	if f.Debug {
+		level = "debug"
+	}
+	return slogf.InitFromConfig(slogf.Config{Level: level, Format: f.Format, Output: f.Output})
+}
+
+//
+// PersistentPreRunE() returns a cobra PersistentPreRunE initializing slogf from f, then calling
+// next when not nil.
+func (f *Flags) PersistentPreRunE(next func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := f.Init(); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(cmd, args)
+		}
+		return nil
+	}
+}
+
+//
+// Setup() binds the flags to the persistent flags of root and initializes slogf before any of its
+// commands runs, keeping the PersistentPreRunE of root, if any.
+func Setup(root *cobra.Command) *Flags {
+	f := BindFlags(root.PersistentFlags())
+	root.PersistentPreRunE = f.PersistentPreRunE(root.PersistentPreRunE)
+	return f
+}
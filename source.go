@@ -0,0 +1,119 @@
+package slogf
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//
+// Source formats of WithSourceFormat().
+const (
+	// SourceBase is the base name of the source file, main.go, the default.
+	SourceBase = "base"
+	// SourceRelative is the path from the root of the module, cmd/api/main.go, which stays unique in
+	// large repos. Files outside of the module, or when the root is unknown, keep their full path.
+	SourceRelative = "relative"
+	// SourceFull is the full path of the source file, as WithFullSource(true).
+	SourceFull = "full"
+	// SourceLine replaces the source object by a single "main.go:29" string, with the base name, for
+	// the backends that index it as one field.
+	SourceLine = "line"
+)
+
+var (
+	// sourceFormat is the source format of the records, set by Init().
+	sourceFormat atomic.Value
+	// moduleRoots caches the module root of a directory, "" when none.
+	moduleRoots sync.Map
+	mainModule  = sync.OnceValue(func() string {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			return info.Main.Path
+		}
+		return ""
+	})
+)
+
+//
+// WithSourceFormat() sets how the source file of the records is shown, one of the Source*
+// constants, as the log backends index it differently.
+// E.g. WithSourceFormat(SourceRelative)
+func WithSourceFormat(format string) Option {
+	return func(s *settings) {
+		f := strings.ToLower(format)
+		switch f {
+		case SourceBase, SourceRelative, SourceFull, SourceLine:
+			s.sourceFormat = f
+		case "":
+			s.sourceFormat = SourceBase
+		default:
+			s.errs = append(s.errs, fmt.Errorf("slogf: unknown source format %q, want base, relative, full or line", format))
+		}
+	}
+}
+
+//
+// sourceFile() returns file as the source format shows it.
+func sourceFile(file string) string {
+	switch f, _ := sourceFormat.Load().(string); f {
+	case SourceFull:
+		return file
+	case SourceRelative:
+		return relativeSource(file)
+	}
+	return filepath.Base(file)
+}
+
+//
+// sourceValue() returns the source attr value of source, a string for SourceLine.
+func sourceValue(source *slog.Source) slog.Value {
+	if f, _ := sourceFormat.Load().(string); f == SourceLine {
+		return slog.StringValue(filepath.Base(source.File) + ":" + strconv.Itoa(source.Line))
+	}
+	source.File = sourceFile(source.File)
+	return slog.AnyValue(source)
+}
+
+//
+// relativeSource() returns file from the root of its module. Binaries built with -trimpath have
+// the paths of the main module start with its path, the others have the absolute path of the
+// file, whose root is the nearest directory with a go.mod, on the hosts having the sources.
+func relativeSource(file string) string {
+	if m := mainModule(); m != "" && strings.HasPrefix(file, m+"/") {
+		return file[len(m)+1:]
+	}
+	if !filepath.IsAbs(file) {
+		return file
+	}
+	dir := filepath.Dir(file)
+	root, ok := moduleRoots.Load(dir)
+	if !ok {
+		root = findModuleRoot(dir)
+		moduleRoots.Store(dir, root)
+	}
+	if r := root.(string); r != "" {
+		if rel, err := filepath.Rel(r, file); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return file
+}
+
+func findModuleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
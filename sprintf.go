@@ -0,0 +1,120 @@
+package slogf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+//
+// sprintf() is fmt.Sprintf() with a fast path for the common verbs %s, %d and %v without flags,
+// on strings, integers, bools, floats and errors. Anything else, including a verb and argument
+// count mismatch, falls back to fmt so the output is always the same as fmt.Sprintf().
+func sprintf(format string, args []any) string {
+	if s, ok := fastSprintf(format, args); ok {
+		return s
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func fastSprintf(format string, args []any) (s string, ok bool) {
+	// fmt recovers from panicking Error() and String() methods, leave those to it.
+	defer func() {
+		if recover() != nil {
+			s, ok = "", false
+		}
+	}()
+
+	var buf [256]byte
+	b := buf[:0]
+	n := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b = append(b, c)
+			continue
+		}
+		i++
+		if i == len(format) {
+			return "", false
+		}
+		verb := format[i]
+		if verb == '%' {
+			b = append(b, '%')
+			continue
+		}
+		if n == len(args) || (verb != 's' && verb != 'd' && verb != 'v') {
+			return "", false
+		}
+		if b, ok = appendArg(b, verb, args[n]); !ok {
+			return "", false
+		}
+		n++
+	}
+	if n != len(args) {
+		return "", false
+	}
+	return string(b), true
+}
+
+func appendArg(b []byte, verb byte, arg any) ([]byte, bool) {
+	switch a := arg.(type) {
+	case string:
+		if verb == 'd' {
+			return b, false
+		}
+		return append(b, a...), true
+	case int:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendInt(b, int64(a), 10), true
+	case int64:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendInt(b, a, 10), true
+	case int32:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendInt(b, int64(a), 10), true
+	case uint:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendUint(b, uint64(a), 10), true
+	case uint64:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendUint(b, a, 10), true
+	case uint32:
+		if verb == 's' {
+			return b, false
+		}
+		return strconv.AppendUint(b, uint64(a), 10), true
+	case bool:
+		if verb != 'v' {
+			return b, false
+		}
+		return strconv.AppendBool(b, a), true
+	case float64:
+		if verb != 'v' {
+			return b, false
+		}
+		return strconv.AppendFloat(b, a, 'g', -1, 64), true
+	case float32:
+		if verb != 'v' {
+			return b, false
+		}
+		return strconv.AppendFloat(b, float64(a), 'g', -1, 32), true
+	case fmt.Formatter:
+		return b, false
+	case error:
+		if verb == 'd' {
+			return b, false
+		}
+		return append(b, a.Error()...), true
+	}
+	return b, false
+}
@@ -0,0 +1,71 @@
+package slogf
+
+import (
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+//
+// Maximum number of frames captured by Stack().
+const maxStackFrames = 64
+
+//
+// StackFrame is one frame of the structured stack representation.
+type StackFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+type stackTrace []StackFrame
+
+var (
+	structuredStacks atomic.Bool
+)
+
+//
+// SetStructuredStacks() makes Stack() attrs render in JSON output as an array of frames,
+// stack=[{"func":...,"file":...,"line":...},...], so SIEM queries can filter by frame,
+// rather than as one newline-joined string. Text output always uses the string.
+func SetStructuredStacks(on bool) {
+	structuredStacks.Store(on)
+}
+
+//
+// Stack() captures the stack of the caller as a "stack" attr.
+// E.g. Error("Invariant broken.", Stack())
+func Stack() slog.Attr {
+	pcs := make([]uintptr, maxStackFrames)
+	n := runtime.Callers(2, pcs) // skip [Callers, Stack]
+	frames := runtime.CallersFrames(pcs[:n])
+	var st stackTrace
+	for {
+		f, more := frames.Next()
+		st = append(st, StackFrame{Func: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	return slog.Any("stack", st)
+}
+
+func (st stackTrace) LogValue() slog.Value {
+	if structuredStacks.Load() && !textFormat.Load() {
+		return slog.AnyValue([]StackFrame(st))
+	}
+	var b strings.Builder
+	for i, f := range st {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.Func)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+	}
+	return slog.StringValue(b.String())
+}
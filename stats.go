@@ -0,0 +1,49 @@
+package slogf
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	startTime = time.Now()
+
+	// Counters of the log pipeline.
+	recordsDebug, recordsInfo, recordsWarn, recordsError, recordsFatal atomic.Int64
+	// droppedRecords are held back by Suppress(), StartErrorSummary() or the pressure watchdog.
+	droppedRecords atomic.Int64
+	// sinkErrors are failed writes and sends of the handlers and sinks.
+	sinkErrors atomic.Int64
+)
+
+//
+// count() adds r to the per level counters.
+func count(level slog.Level) {
+	switch {
+	case level >= LevelFatal:
+		recordsFatal.Add(1)
+	case level >= slog.LevelError:
+		recordsError.Add(1)
+	case level >= slog.LevelWarn:
+		recordsWarn.Add(1)
+	case level >= slog.LevelInfo:
+		recordsInfo.Add(1)
+	default:
+		recordsDebug.Add(1)
+	}
+}
+
+//
+// pipelineStats() returns the counters as a group attr.
+func pipelineStats() slog.Attr {
+	return slog.Group("records",
+		slog.Int64("debug", recordsDebug.Load()),
+		slog.Int64("info", recordsInfo.Load()),
+		slog.Int64("warn", recordsWarn.Load()),
+		slog.Int64("error", recordsError.Load()),
+		slog.Int64("fatal", recordsFatal.Load()),
+		slog.Int64("dropped", droppedRecords.Load()),
+		slog.Int64("sink_errors", sinkErrors.Load()),
+	)
+}
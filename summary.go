@@ -0,0 +1,127 @@
+package slogf
+
+import (
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Entry of the top list in an error_summary record.
+type ErrorSummaryEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Msg         string `json:"msg"`
+	Count       int    `json:"count"`
+}
+
+type errorSummarizer struct {
+	keep int
+	done chan struct{}
+
+	mu     sync.Mutex
+	counts map[string]*ErrorSummaryEntry
+	total  int
+}
+
+var (
+	summarizer atomic.Pointer[errorSummarizer]
+)
+
+//
+// Number of fingerprints listed in the top of an error_summary record.
+const summaryTop = 10
+
+//
+// StartErrorSummary() aggregates ERROR records by fingerprint, the call site and the message with
+// digits masked. Within every interval the first keep records of a fingerprint are written as usual,
+// the rest are only counted, and one "error_summary" ERROR record lists the totals at the end of the
+// interval, a minute when the interval isn't positive. FATAL records are never held back. Call the
+// returned function to stop it.
+func StartErrorSummary(interval time.Duration, keep int) (stop func()) {
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	s := &errorSummarizer{keep: keep, done: make(chan struct{}), counts: map[string]*ErrorSummaryEntry{}}
+	if old := summarizer.Swap(s); old != nil {
+		old.stop()
+	}
+	t := time.NewTicker(interval)
+	go func() {
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				s.flush()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return func() {
+		if summarizer.CompareAndSwap(s, nil) {
+			s.stop()
+		}
+	}
+}
+
+func (s *errorSummarizer) stop() {
+	close(s.done)
+	s.flush()
+}
+
+//
+// summarize() counts r and tells whether it should still be written.
+func (s *errorSummarizer) summarize(r slog.Record) bool {
+	fp := fingerprint(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.counts[fp]
+	if !ok {
+		e = &ErrorSummaryEntry{Fingerprint: fp, Msg: r.Message}
+		s.counts[fp] = e
+	}
+	e.Count++
+	s.total++
+	return e.Count <= s.keep
+}
+
+func (s *errorSummarizer) flush() {
+	s.mu.Lock()
+	counts, total := s.counts, s.total
+	s.counts, s.total = map[string]*ErrorSummaryEntry{}, 0
+	s.mu.Unlock()
+	if total == 0 {
+		return
+	}
+
+	top := make([]ErrorSummaryEntry, 0, len(counts))
+	for _, e := range counts {
+		top = append(top, *e)
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].Count > top[j].Count })
+	distinct := len(top)
+	if len(top) > summaryTop {
+		top = top[:summaryTop]
+	}
+	notice(slog.LevelError, "error_summary",
+		slog.Int("count", total),
+		slog.Int("distinct", distinct),
+		slog.Any("top", top),
+	)
+}
+
+//
+// fingerprint() identifies a kind of record, by call site and message with the digits masked,
+// so "retry 3 of 5" and "retry 4 of 5" from one line are the same failure.
+func fingerprint(r slog.Record) string {
+	msg := []byte(r.Message)
+	for i, c := range msg {
+		if c >= '0' && c <= '9' {
+			msg[i] = '#'
+		}
+	}
+	return hashSum([]byte(strconv.FormatUint(uint64(r.PC), 16)), msg)
+}
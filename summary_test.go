@@ -0,0 +1,52 @@
+package slogf
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestErrorSummary(t *testing.T) {
+	defer Reinit(WithOutput(io.Discard))
+	var b bytes.Buffer
+	if err := Reinit(WithLevel(slog.LevelInfo), WithFormat("json"), WithOutput(&b)); err != nil {
+		t.Fatal(err)
+	}
+	// A zero interval falls back to the default one rather than panicking.
+	stop := StartErrorSummary(0, 1)
+	for i := 0; i < 3; i++ {
+		Logger.Error("retry failed", "attempt", i)
+	}
+	stop()
+	stop()
+
+	var msgs []string
+	var summary struct {
+		Count    int                 `json:"count"`
+		Distinct int                 `json:"distinct"`
+		Top      []ErrorSummaryEntry `json:"top"`
+	}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		var r struct {
+			Msg string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, r.Msg)
+		if r.Msg == "error_summary" {
+			if err := json.Unmarshal([]byte(line), &summary); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if strings.Join(msgs, ",") != "retry failed,error_summary" {
+		t.Fatalf("got records %q", msgs)
+	}
+	if summary.Count != 3 || summary.Distinct != 1 || len(summary.Top) != 1 || summary.Top[0].Count != 3 || summary.Top[0].Msg != "retry failed" {
+		t.Errorf("got summary %+v", summary)
+	}
+}
@@ -0,0 +1,85 @@
+package slogf
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type suppression struct {
+	pred  func(r slog.Record) bool
+	until time.Time
+	count atomic.Int64
+	timer *time.Timer
+	once  sync.Once
+}
+
+var (
+	suppressMu   sync.RWMutex
+	suppressions []*suppression
+	// suppressing counts the active suppressions to keep the path cheap when there are none.
+	suppressing atomic.Int32
+)
+
+//
+// Suppress() silences the records matching pred until the given time, e.g. a known-noisy message
+// during planned maintenance. When the suppression expires, or the returned lift function is called,
+// an INFO record summarizes how many records were suppressed.
+// E.g. Suppress(MatchMessage("connection refused"), time.Now().Add(30*time.Minute))
+func Suppress(pred func(r slog.Record) bool, until time.Time) (lift func()) {
+	s := &suppression{pred: pred, until: until}
+	suppressing.Add(1)
+	suppressMu.Lock()
+	defer suppressMu.Unlock()
+	suppressions = append(suppressions, s)
+	// end() takes suppressMu, so it sees the timer even when until has passed already.
+	s.timer = time.AfterFunc(until.Sub(now()), s.end)
+	return s.end
+}
+
+//
+// MatchMessage() is a Suppress() predicate matching records whose message contains substr.
+func MatchMessage(substr string) func(r slog.Record) bool {
+	return func(r slog.Record) bool {
+		return strings.Contains(r.Message, substr)
+	}
+}
+
+func (s *suppression) end() {
+	s.once.Do(func() {
+		suppressMu.Lock()
+		s.timer.Stop()
+		for i, x := range suppressions {
+			if x == s {
+				suppressions = append(suppressions[:i:i], suppressions[i+1:]...)
+				break
+			}
+		}
+		suppressMu.Unlock()
+		suppressing.Add(-1)
+
+		notice(slog.LevelInfo, "slogf: suppression ended",
+			slog.Int64("suppressed", s.count.Load()),
+			slog.Time("until", s.until))
+	})
+}
+
+//
+// suppressed() tells whether r matches an active suppression, counting it if so.
+func suppressed(r slog.Record) bool {
+	if suppressing.Load() == 0 {
+		return false
+	}
+	t := now()
+	suppressMu.RLock()
+	defer suppressMu.RUnlock()
+	for _, s := range suppressions {
+		if t.Before(s.until) && s.pred(r) {
+			s.count.Add(1)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package slogf
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+)
+
+//
+// syncKey is the key of the attr of Sync(), which is never written out.
+const syncKey = "!slogf.sync"
+
+//
+// Sync() returns a logger whose records are written out before its methods return: the sharded
+// output and the buffering sinks are flushed and the output file is synced to disk, for the few
+// records that must be on disk before a risky operation. It costs a flush per record.
+// E.g. Sync().Warn("Migrating the schema.", "version", v)
+func Sync() *slog.Logger {
+	return Logger.With(slog.Bool(syncKey, true))
+}
+
+//
+// flushNow() flushes every sink and syncs the output when it is a file.
+func flushNow() error {
+	err := closeSinks(false)
+	outputMu.Lock()
+	w := output
+	outputMu.Unlock()
+	if f, ok := w.(interface{ Sync() error }); ok && w != os.Stdout && w != os.Stderr {
+		err = errors.Join(err, f.Sync())
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type targetRule struct {
+	key   string
+	value string
+	level slog.Level
+	until time.Time
+}
+
+var (
+	targetsMu sync.RWMutex
+	targets   []*targetRule
+	// targeting counts the rules to keep the path cheap when there are none.
+	targeting atomic.Int32
+)
+
+//
+// Target() sets the effective level of the records whose context attrs contain key=value, until
+// the given time or the returned remove function is called, so support engineers can capture
+// verbose logs of a single affected customer in production. Context attrs are those of
+// PushFields(), PropagateBaggage() and the trace.
+// E.g. Target("user_id", "123", slog.LevelDebug, time.Now().Add(time.Hour))
+func Target(key, value string, level slog.Level, until time.Time) (remove func()) {
+	t := &targetRule{key: key, value: value, level: level, until: until}
+	targetsMu.Lock()
+	targets = append(targets, t)
+	targetsMu.Unlock()
+	targeting.Add(1)
+	notice(slog.LevelInfo, "slogf: target added",
+		slog.String("key", key), slog.String("value", value), slog.Any("level", level), slog.Time("until", until))
+
+	var once sync.Once
+	remove = func() {
+		once.Do(func() {
+			targetsMu.Lock()
+			for i, x := range targets {
+				if x == t {
+					targets = append(targets[:i:i], targets[i+1:]...)
+					break
+				}
+			}
+			targetsMu.Unlock()
+			targeting.Add(-1)
+		})
+	}
+	time.AfterFunc(time.Until(until), remove)
+	return remove
+}
+
+//
+// targetLevel() returns the lowest level the active rules give the context attrs of ctx.
+func targetLevel(ctx context.Context) (level slog.Level, ok bool) {
+	if targeting.Load() == 0 {
+		return 0, false
+	}
+	attrs := contextAttrs(ctx)
+	if len(attrs) == 0 {
+		return 0, false
+	}
+	now := time.Now()
+	targetsMu.RLock()
+	defer targetsMu.RUnlock()
+	for _, t := range targets {
+		if now.After(t.until) || (ok && t.level >= level) {
+			continue
+		}
+		for _, a := range attrs {
+			if a.Key == t.key && a.Value.Resolve().String() == t.value {
+				level, ok = t.level, true
+				break
+			}
+		}
+	}
+	return level, ok
+}
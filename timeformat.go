@@ -0,0 +1,93 @@
+package slogf
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Time formats of WithTimeFormat().
+const (
+	// TimeDefault is slog's, RFC 3339 with nanoseconds in JSON and milliseconds in text.
+	TimeDefault = ""
+	// TimeRFC3339Nano is RFC 3339 with as many fraction digits as needed, up to nanoseconds.
+	TimeRFC3339Nano = "rfc3339nano"
+	// TimeRFC3339, TimeRFC3339Millis and TimeRFC3339Micros are RFC 3339 with a fixed precision, so
+	// the lines sort as text.
+	TimeRFC3339       = "rfc3339"
+	TimeRFC3339Millis = "rfc3339ms"
+	TimeRFC3339Micros = "rfc3339us"
+	// TimeUnix and TimeUnixMillis are numbers of seconds or milliseconds since the epoch.
+	TimeUnix       = "unix"
+	TimeUnixMillis = "unixms"
+)
+
+var (
+	// timeFormat is the format of the time of the records, set by Init(), timeZone its zone.
+	timeFormat atomic.Value
+	timeZone   atomic.Pointer[time.Location]
+)
+
+//
+// WithTimeFormat() sets the format of the time of the records, one of the Time* constants, e.g.
+// WithTimeFormat(TimeUnixMillis) for the pipelines that want epoch milliseconds.
+func WithTimeFormat(format string) Option {
+	return func(s *settings) {
+		f := strings.ToLower(format)
+		switch f {
+		case TimeDefault, TimeRFC3339Nano, TimeRFC3339, TimeRFC3339Millis, TimeRFC3339Micros, TimeUnix, TimeUnixMillis:
+			s.timeFormat = f
+		default:
+			s.errs = append(s.errs, fmt.Errorf("slogf: unknown time format %q, want rfc3339nano, rfc3339, rfc3339ms, rfc3339us, unix or unixms", format))
+		}
+	}
+}
+
+//
+// WithUTC() writes the time of the records in UTC rather than the local time of the host.
+func WithUTC() Option {
+	return WithTimezone(time.UTC)
+}
+
+//
+// WithTimezone() writes the time of the records in loc whatever the zone of the host, so a fleet
+// spread across regions logs in one zone.
+// E.g. loc, _ := time.LoadLocation("Europe/Paris"); Init(WithTimezone(loc))
+func WithTimezone(loc *time.Location) Option {
+	return func(s *settings) {
+		if loc == nil {
+			s.errs = append(s.errs, errors.New("slogf: nil timezone"))
+			return
+		}
+		s.timeZone = loc
+	}
+}
+
+//
+// formatTime() returns t in the format and zone set by Init(), ok false for the default ones.
+func formatTime(t time.Time) (slog.Value, bool) {
+	loc := timeZone.Load()
+	if loc != nil {
+		t = t.In(loc)
+	}
+	f, _ := timeFormat.Load().(string)
+	switch f {
+	case TimeRFC3339Nano:
+		return slog.StringValue(t.Format(time.RFC3339Nano)), true
+	case TimeRFC3339:
+		return slog.StringValue(t.Format(time.RFC3339)), true
+	case TimeRFC3339Millis:
+		return slog.StringValue(t.Format("2006-01-02T15:04:05.000Z07:00")), true
+	case TimeRFC3339Micros:
+		return slog.StringValue(t.Format("2006-01-02T15:04:05.000000Z07:00")), true
+	case TimeUnix:
+		return slog.Int64Value(t.Unix()), true
+	case TimeUnixMillis:
+		return slog.Int64Value(t.UnixMilli()), true
+	}
+	return slog.TimeValue(t), loc != nil
+}
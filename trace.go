@@ -0,0 +1,92 @@
+package slogf
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+//
+// Keys of the trace attrs.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+//
+// Trace is the W3C trace context of a request.
+type Trace struct {
+	TraceID    string
+	SpanID     string
+	Flags      string
+	TraceState string
+}
+
+type traceKey struct{}
+
+//
+// TraceFromHeaders() parses the W3C traceparent and tracestate headers, for services without a full
+// OTel SDK, and returns a context carrying them. Records logged with it, or a context derived from
+// it, get the trace_id and span_id attrs for correlation.
+// E.g.
+//
+//	ctx := TraceFromHeaders(r.Header)
+//	InfoContext(ctx, "Request received.")
+func TraceFromHeaders(h http.Header) context.Context {
+	return ContextWithTrace(context.Background(), h)
+}
+
+//
+// ContextWithTrace() is TraceFromHeaders() deriving from ctx, e.g. the request context.
+func ContextWithTrace(ctx context.Context, h http.Header) context.Context {
+	t, ok := parseTraceparent(h.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	t.TraceState = h.Get("tracestate")
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+//
+// TraceFromContext() returns the trace context set by TraceFromHeaders() or ContextWithTrace().
+func TraceFromContext(ctx context.Context) (Trace, bool) {
+	if ctx == nil {
+		return Trace{}, false
+	}
+	t, ok := ctx.Value(traceKey{}).(Trace)
+	return t, ok
+}
+
+//
+// parseTraceparent() parses "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func parseTraceparent(v string) (Trace, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return Trace{}, false
+	}
+	// Version 00 has exactly 4 fields, later versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return Trace{}, false
+	}
+	for _, p := range parts[:4] {
+		if _, err := hex.DecodeString(p); err != nil || p != strings.ToLower(p) {
+			return Trace{}, false
+		}
+	}
+	if parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return Trace{}, false
+	}
+	return Trace{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}, true
+}
+
+//
+// traceAttrs() returns the trace attrs of ctx.
+func traceAttrs(ctx context.Context) []slog.Attr {
+	t, ok := TraceFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []slog.Attr{slog.String(TraceIDKey, t.TraceID), slog.String(SpanIDKey, t.SpanID)}
+}
@@ -0,0 +1,195 @@
+package slogf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	validation atomic.Bool
+)
+
+//
+// EnableValidation() turns on checking of outgoing records against the attribute schemas, meant for
+// tests and development. Every attr whose key has a schema, from RegisterAttrSchema() or LoadSchema(),
+// is checked and each violation is logged as a WARN diagnostic at the call site of the record.
+func EnableValidation(on bool) {
+	validation.Store(on)
+}
+
+//
+// LoadSchema() registers the properties of a user provided JSON Schema document as attribute schemas.
+// The supported keywords are type, enum, properties and required.
+func LoadSchema(doc []byte) error {
+	var s struct {
+		Properties map[string]map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(doc, &s); err != nil {
+		return fmt.Errorf("slogf: invalid schema: %w", err)
+	}
+	for k, v := range s.Properties {
+		RegisterAttrSchema(k, v)
+	}
+	return nil
+}
+
+func (h *handler) validate(ctx context.Context, r slog.Record) {
+	attrSchemasMu.RLock()
+	defer attrSchemasMu.RUnlock()
+	if len(attrSchemas) == 0 {
+		return
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if s, ok := attrSchemas[a.Key]; ok {
+			if err := checkSchema(s, valueToAny(a.Value), a.Key); err != nil {
+				h.diagnose(ctx, r, slog.LevelWarn, "slogf: record violates schema", "record_msg", r.Message, "key", a.Key, "reason", err.Error())
+			}
+		}
+		return true
+	})
+}
+
+//
+// valueToAny() converts v to the value the JSON handler would write, made of maps, slices and scalars.
+func valueToAny(v slog.Value) any {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return int64(v.Duration())
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindGroup:
+		m := make(map[string]any, len(v.Group()))
+		for _, a := range v.Group() {
+			addAttrToMap(m, a)
+		}
+		return m
+	}
+	x := v.Any()
+	if err, ok := x.(error); ok {
+		return err.Error()
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return fmt.Sprintf("%+v", x)
+	}
+	var out any
+	if json.Unmarshal(b, &out) != nil {
+		return string(b)
+	}
+	return out
+}
+
+//
+// checkSchema() checks x against the supported subset of JSON Schema.
+func checkSchema(s map[string]any, x any, path string) error {
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, v := range t {
+				if v, ok := v.(string); ok {
+					types = append(types, v)
+				}
+			}
+		}
+		if len(types) > 0 && !hasType(types, x) {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), jsonType(x))
+		}
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(x) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, x, enum)
+		}
+	}
+	m, isObject := x.(map[string]any)
+	if !isObject {
+		return nil
+	}
+	if required, ok := s["required"].([]any); ok {
+		for _, k := range required {
+			if k, ok := k.(string); ok {
+				if _, ok := m[k]; !ok {
+					return fmt.Errorf("%s: missing required %s", path, k)
+				}
+			}
+		}
+	}
+	if props, ok := s["properties"].(map[string]any); ok {
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, ok := props[k].(map[string]any)
+			v, present := m[k]
+			if !ok || !present {
+				continue
+			}
+			if err := checkSchema(ps, v, path+"."+k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasType(types []string, x any) bool {
+	got := jsonType(x)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(x any) string {
+	switch x := x.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int64, uint64:
+		return "integer"
+	case float64:
+		if x == math.Trunc(x) && !math.IsInf(x, 0) {
+			return "integer"
+		}
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	return "unknown"
+}
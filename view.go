@@ -0,0 +1,110 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+//
+// View declares which attrs a sink gets, e.g. full detail for the local debug file and a minimized,
+// redacted subset for the cloud sink. Keys are dotted paths, "http.path" for path in group http.
+// Drop wins over Keep, and an empty Keep keeps everything.
+type View struct {
+	Keep   []string
+	Drop   []string
+	Redact []string
+}
+
+type viewHandler struct {
+	next   slog.Handler
+	view   *View
+	prefix string // dotted path of the groups opened with WithGroup()
+}
+
+//
+// WithView() wraps a sink so it only receives the projection of every record described by v.
+// E.g. AddSink(WithView(cloud, View{Drop: []string{"request.body"}, Redact: []string{"user.email"}}))
+func WithView(h slog.Handler, v View) slog.Handler {
+	return &viewHandler{next: h, view: &v}
+}
+
+func (h *viewHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *viewHandler) Handle(ctx context.Context, r slog.Record) error {
+	projected := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := h.view.project(h.prefix, a); ok {
+			projected.AddAttrs(a)
+		}
+		return true
+	})
+	return h.next.Handle(ctx, projected)
+}
+
+func (h *viewHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	projected := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := h.view.project(h.prefix, a); ok {
+			projected = append(projected, a)
+		}
+	}
+	return &viewHandler{next: h.next.WithAttrs(projected), view: h.view, prefix: h.prefix}
+}
+
+func (h *viewHandler) WithGroup(name string) slog.Handler {
+	return &viewHandler{next: h.next.WithGroup(name), view: h.view, prefix: h.prefix + name + "."}
+}
+
+func (h *viewHandler) unwrap() slog.Handler {
+	return h.next
+}
+
+//
+// project() applies the view to a, found under the dotted prefix. ok is false when a is dropped.
+func (v *View) project(prefix string, a slog.Attr) (_ slog.Attr, ok bool) {
+	path := prefix + a.Key
+	if a.Key == "" {
+		// Inlined group, its attrs are at the prefix level.
+		path = strings.TrimSuffix(prefix, ".")
+	}
+	if matchPath(v.Drop, path) {
+		return a, false
+	}
+	if matchPath(v.Redact, path) {
+		return slog.String(a.Key, Redacted), true
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = path + "."
+		}
+		var attrs []slog.Attr
+		for _, g := range a.Value.Group() {
+			if g, ok := v.project(groupPrefix, g); ok {
+				attrs = append(attrs, g)
+			}
+		}
+		if len(attrs) == 0 {
+			return a, false
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, true
+	}
+	if len(v.Keep) > 0 && !matchPath(v.Keep, path) {
+		return a, false
+	}
+	return a, true
+}
+
+//
+// matchPath() tells whether path is one of paths or lies under one of them.
+func matchPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package slogf
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+//
+// Interval at which WatchConfig() checks the config file.
+const configPollInterval = 2 * time.Second
+
+//
+// WatchConfig() sets up the global logger from the config file, as InitFromFile(), then re-reads it
+// whenever its content changes and reconfigures the logger with ApplyConfig(), without restarting
+// the process or losing records.
+// The file is polled, which also follows Kubernetes ConfigMap updates. An invalid new version is
+// reported by a WARN record and the current configuration kept. Call the returned function to stop.
+func WatchConfig(path string) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := InitFromFile(path); err != nil {
+		return nil, err
+	}
+	last := hashSum(data)
+	t := time.NewTicker(configPollInterval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				data, err := os.ReadFile(path)
+				if err != nil {
+					continue
+				}
+				sum := hashSum(data)
+				if sum == last {
+					continue
+				}
+				last = sum
+				cfg, err := LoadConfig(path)
+				if err == nil {
+					err = ApplyConfig(cfg)
+				}
+				if err != nil {
+					notice(slog.LevelWarn, "slogf: config not reloaded", slog.String("path", path), slog.String("error", err.Error()))
+					continue
+				}
+				notice(slog.LevelInfo, "slogf: config reloaded", slog.String("path", path))
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Stop()
+			close(done)
+		})
+	}, nil
+}
@@ -0,0 +1,106 @@
+package slogf
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+// Number of missed intervals after which a stalled operation is reported at ERROR level.
+const watchdogEscalation = 3
+
+//
+// WatchdogTimer watches one operation, see Watchdog().
+type WatchdogTimer struct {
+	name     string
+	interval time.Duration
+	pc       uintptr
+	start    time.Time
+	last     atomic.Int64 // unix nanos of the last Ping()
+	done     chan struct{}
+	once     sync.Once
+}
+
+//
+// Watchdog() watches a long operation and logs a WARN record every interval it doesn't report
+// progress with Ping(), with the time it has been stalled, escalating to ERROR after 3 missed
+// intervals, so silent hangs show up in the logs. The records have the source of the Watchdog() call.
+// The interval is a minute when it isn't positive.
+// E.g.
+//
+//	w := Watchdog("migration", 30*time.Second)
+//	defer w.Stop()
+//	for _, m := range migrations {
+//	    m.Run()
+//	    w.Ping()
+//	}
+func Watchdog(name string, interval time.Duration) *WatchdogTimer {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:]) // skip [Callers, Watchdog]
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	w := &WatchdogTimer{name: name, interval: interval, pc: pcs[0], start: time.Now(), done: make(chan struct{})}
+	w.last.Store(w.start.UnixNano())
+	go w.watch(time.NewTicker(interval))
+	return w
+}
+
+//
+// Ping() reports progress of the operation.
+func (w *WatchdogTimer) Ping() {
+	w.last.Store(time.Now().UnixNano())
+}
+
+//
+// Stop() ends the watch, when the operation is done.
+func (w *WatchdogTimer) Stop() {
+	w.once.Do(func() { close(w.done) })
+}
+
+func (w *WatchdogTimer) watch(t *time.Ticker) {
+	defer t.Stop()
+	missed := 0
+	reported := int64(0)
+	for {
+		select {
+		case <-t.C:
+			last := w.last.Load()
+			stalled := time.Since(time.Unix(0, last))
+			if stalled < w.interval {
+				missed = 0
+				continue
+			}
+			if last != reported {
+				missed = 0
+				reported = last
+			}
+			missed++
+			level := slog.LevelWarn
+			if missed >= watchdogEscalation {
+				level = slog.LevelError
+			}
+			w.report(level, stalled, missed)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *WatchdogTimer) report(level slog.Level, stalled time.Duration, missed int) {
+	if !Logger.Enabled(context.Background(), level) {
+		return
+	}
+	r := slog.NewRecord(now(), level, "slogf: operation stalled", w.pc)
+	r.AddAttrs(
+		slog.String("operation", w.name),
+		slog.Duration("stalled", stalled.Round(time.Millisecond)),
+		slog.Int("missed", missed),
+		slog.Duration("running", time.Since(w.start).Round(time.Millisecond)),
+	)
+	_ = Logger.Handler().Handle(context.Background(), r)
+}
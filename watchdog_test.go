@@ -0,0 +1,17 @@
+package slogf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		w := Watchdog("migration", interval)
+		w.Stop()
+		w.Stop()
+		if w.interval != defaultHeartbeatInterval {
+			t.Errorf("Watchdog(%v) interval = %v, want %v", interval, w.interval, defaultHeartbeatInterval)
+		}
+	}
+}
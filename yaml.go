@@ -0,0 +1,349 @@
+package slogf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//
+// yamlLine is a significant line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+//
+// parseYAML() parses the subset of YAML used by config files into maps, slices and scalars:
+// block mappings and sequences, plain and quoted scalars, comments and flow sequences of scalars.
+// Booleans and numbers are yamlPlain values, see yamlStrings().
+// Anchors, tags, multi-line scalars and multiple documents are not supported.
+func parseYAML(data []byte) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(raw, "---") || strings.HasPrefix(raw, "...") {
+			continue
+		}
+		lead := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+		if strings.Contains(lead, "\t") && strings.TrimSpace(raw) != "" {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed in indentation", i+1)
+		}
+		text := strings.TrimRight(stripYAMLComment(raw), " \t")
+		content := strings.TrimLeft(text, " ")
+		if content == "" {
+			continue
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(text) - len(content), text: content})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.node(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", p.lines[p.i].num)
+	}
+	return v, nil
+}
+
+//
+// yamlPlain is a plain scalar read as a boolean or a number, text keeping it as written for the
+// string fields, e.g. "no" for a region or "2" for a version.
+type yamlPlain struct {
+	text  string
+	value any
+}
+
+func (p yamlPlain) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.value)
+}
+
+//
+// yamlStrings() replaces the plain scalars of v decoded into a string of t, following the json
+// tags of the structs, by their text.
+func yamlStrings(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch x := v.(type) {
+	case yamlPlain:
+		if t.Kind() == reflect.String {
+			return x.text
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i := range x {
+				x[i] = yamlStrings(x[i], t.Elem())
+			}
+		}
+	case map[string]any:
+		switch t.Kind() {
+		case reflect.Map:
+			for k := range x {
+				x[k] = yamlStrings(x[k], t.Elem())
+			}
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				if name == "" {
+					name = f.Name
+				}
+				if fv, ok := x[name]; ok {
+					x[name] = yamlStrings(fv, f.Type)
+				}
+			}
+		}
+	}
+	return v
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func (p *yamlParser) node(indent int) (any, error) {
+	if isYAMLItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && !isYAMLItem(p.lines[p.i].text) {
+		l := p.lines[p.i]
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected key: value", l.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.num, key)
+		}
+		p.i++
+		if rest != "" {
+			v, err := yamlScalar(rest, l.num)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			continue
+		}
+		// A nested block, or a sequence which may sit at the indent of its key.
+		if p.i < len(p.lines) {
+			next := p.lines[p.i]
+			if next.indent > indent || (next.indent == indent && isYAMLItem(next.text)) {
+				v, err := p.node(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = v
+				continue
+			}
+		}
+		m[key] = nil
+	}
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	s := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLItem(p.lines[p.i].text) {
+		l := p.lines[p.i]
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		if rest == "" {
+			p.i++
+			if p.i < len(p.lines) && p.lines[p.i].indent > indent {
+				v, err := p.node(p.lines[p.i].indent)
+				if err != nil {
+					return nil, err
+				}
+				s = append(s, v)
+			} else {
+				s = append(s, nil)
+			}
+			continue
+		}
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLItem(rest) {
+			// The item is a block starting on the line of its dash, e.g. "- name: x".
+			p.lines[p.i] = yamlLine{num: l.num, indent: indent + len(l.text) - len(rest), text: rest}
+			v, err := p.node(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			continue
+		}
+		p.i++
+		v, err := yamlScalar(rest, l.num)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+//
+// splitYAMLKey() splits "key: value", the key possibly quoted.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key, text = text[1:end+1], text[end+2:]
+		if !strings.HasPrefix(text, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(text[1:]), true
+	}
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key = strings.TrimSpace(text[:i])
+	if key == "" || strings.ContainsAny(key[:1], "[{") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[i+1:]), true
+}
+
+func yamlScalar(s string, num int) (any, error) {
+	switch s[0] {
+	case '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: bad quoted string %s", num, s)
+		}
+		return v, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("yaml: line %d: bad quoted string %s", num, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow sequence", num)
+		}
+		items := []any{}
+		if inner := strings.TrimSpace(s[1 : len(s)-1]); inner != "" {
+			for _, item := range splitYAMLFlow(inner) {
+				item = strings.TrimSpace(item)
+				if item == "" {
+					return nil, fmt.Errorf("yaml: line %d: empty flow item", num)
+				}
+				v, err := yamlScalar(item, num)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			}
+		}
+		return items, nil
+	case '{':
+		if s == "{}" {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("yaml: line %d: flow mappings are not supported", num)
+	case '|', '>', '&', '*', '!':
+		return nil, fmt.Errorf("yaml: line %d: %q is not supported", num, s[:1])
+	}
+	switch s {
+	case "true", "True", "TRUE", "yes", "on":
+		return yamlPlain{s, true}, nil
+	case "false", "False", "FALSE", "no", "off":
+		return yamlPlain{s, false}, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return yamlPlain{s, i}, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return yamlPlain{s, f}, nil
+	}
+	return s, nil
+}
+
+//
+// splitYAMLFlow() splits the items of a flow sequence at the commas outside quoted items.
+func splitYAMLFlow(inner string) []string {
+	var items []string
+	var quote byte
+	start, item := true, 0
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(inner) && inner[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == ',':
+			items, start, item = append(items, inner[item:i]), true, i+1
+		case c == ' ':
+		case start && (c == '"' || c == '\''):
+			quote, start = c, false
+		default:
+			start = false
+		}
+	}
+	return append(items, inner[item:])
+}
+
+//
+// stripYAMLComment() removes a comment, a # at the start or after a space, outside quotes. A quote
+// is only a delimiter as the first character of a key, a value or a flow item, e.g. the value of
+// "output: it's.log # c" is plain.
+func stripYAMLComment(line string) string {
+	var quote byte
+	// start is set where a value or a flow item may start, flow inside a flow sequence.
+	start, flow := true, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '\'' && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		case c == ' ' || c == '\t':
+		case start && (c == '"' || c == '\''):
+			quote, start = c, false
+		case start && c == '[':
+			flow = true
+		case flow && c == ',':
+			start = true
+		case flow && c == ']':
+			flow, start = false, false
+		case (start && c == '-') || c == ':':
+			start = i+1 == len(line) || line[i+1] == ' '
+		default:
+			start = false
+		}
+	}
+	return line
+}
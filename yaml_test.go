@@ -0,0 +1,125 @@
+package slogf
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // JSON
+	}{
+		{"empty", "", `{}`},
+		{"scalars", "a: 1\nb: 1.5\nc: true\nd: no\ne: ~\nf: text here", `{"a":1,"b":1.5,"c":true,"d":false,"e":null,"f":"text here"}`},
+		{"double quoted", `a: "x: #1\n"`, `{"a":"x: #1\n"}`},
+		{"single quoted", `a: 'it''s # here'`, `{"a":"it's # here"}`},
+		{"quoted key", `"a b": 1`, `{"a b":1}`},
+		{"quoted scalars stay strings", "a: \"2\"\nb: 'no'", `{"a":"2","b":"no"}`},
+		{"nested", "a:\n  b:\n    c: 1\n  d: 2\ne: 3", `{"a":{"b":{"c":1},"d":2},"e":3}`},
+		{"list", "a:\n  - 1\n  - x", `{"a":[1,"x"]}`},
+		{"list at the key indent", "a:\n- 1\n- 2", `{"a":[1,2]}`},
+		{"list of maps", "sinks:\n  - type: kafka\n    params:\n      topic: logs\n  - type: stderr", `{"sinks":[{"params":{"topic":"logs"},"type":"kafka"},{"type":"stderr"}]}`},
+		{"flow list", "a: [1, x, \"y\"]\nb: []", `{"a":[1,"x","y"],"b":[]}`},
+		{"empty flow map", "a: {}", `{"a":{}}`},
+		{"null value", "a:\nb: 1", `{"a":null,"b":1}`},
+		{"comments", "# head\na: 1 # one\n\n  # indented\nb: x#not a comment", `{"a":1,"b":"x#not a comment"}`},
+		{"document markers", "---\na: 1\n...", `{"a":1}`},
+		{"crlf", "a: 1\r\nb: 2\r\n", `{"a":1,"b":2}`},
+		{"quote inside a plain scalar", "output: it's.log # c\nb: say \"hi\" # c", `{"b":"say \"hi\"","output":"it's.log"}`},
+		{"escaped quotes", `a: "x \" # y" # c` + "\nb: 'it''s # y' # c", `{"a":"x \" # y","b":"it's # y"}`},
+		{"quoted flow items", `a: ["x, # y", 'z', it's] # c`, `{"a":["x, # y","z","it's"]}`},
+		{"quoted item", "a:\n  - 'x # y' # c\n  - it's # c", `{"a":["x # y","it's"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseYAML([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct{ name, in, want string }{
+		{"tab indentation", "a:\n\tb: 1", "line 2: tabs"},
+		{"duplicate key", "a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"not a mapping", "a: 1\njust text", "line 2: expected key: value"},
+		{"bad indentation", "a:\n    b: 1\n  c: 2", "line 3: unexpected indentation"},
+		{"bad quote", `a: "x`, "line 1: bad quoted string"},
+		{"unterminated flow", "a: [1, 2", "line 1: unterminated flow sequence"},
+		{"trailing flow comma", "redact: [password,]", "line 1: empty flow item"},
+		{"empty flow item", "a: 1\nredact: [a, , b]", "line 2: empty flow item"},
+		{"flow mapping", "a: {b: 1}", "flow mappings are not supported"},
+		{"block scalar", "a: |\n  text", `"|" is not supported`},
+		{"anchor", "a: &x 1", `"&" is not supported`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestYAMLStrings(t *testing.T) {
+	v, err := parseYAML([]byte("level_labels:\n  ERROR: 2\nsinks:\n  - type: kafka\n    params:\n      acks: 1\nredact: [yes, 3]\nhost_info: yes\nadd_source: off"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(yamlStrings(v, reflect.TypeOf(Config{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"add_source":false,"host_info":true,"level_labels":{"ERROR":"2"},"redact":["yes","3"],"sinks":[{"params":{"acks":"1"},"type":"kafka"}]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	data := "level: debug\nformat: text # for now\nstatic_fields:\n  service: payments\n  version: 2\n  region: no\n  ratio: 0.5\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"service": "payments", "version": "2", "region": "no", "ratio": "0.5"}
+	if cfg.Level != "debug" || cfg.Format != "text" || !reflect.DeepEqual(cfg.StaticFields, want) {
+		t.Errorf("got %+v", cfg)
+	}
+
+	for _, data := range []string{"redact: [password,]\n", "redact: [a, , b]\n"} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "empty flow item") {
+			t.Errorf("%q: got %v, want an empty flow item error", data, err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("level: debug\nlevels: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `unknown field "levels"`) {
+		t.Errorf("got %v, want an unknown field error", err)
+	}
+}